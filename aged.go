@@ -0,0 +1,35 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge
+
+// Aged defines the optional interface for units that remember the turn
+// they were founded. Legacy data has a founding turn of 0.
+type Aged interface {
+	// Age returns the number of turns since the unit was founded.
+	Age(currentTurn int) int
+	// FoundedTurn returns the turn the unit was founded.
+	FoundedTurn() int
+}
+
+// age returns the turns between the founding turn and the current turn.
+// It is never negative.
+func age(foundedTurn, currentTurn int) int {
+	if currentTurn < foundedTurn {
+		return 0
+	}
+	return currentTurn - foundedTurn
+}
@@ -0,0 +1,69 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge
+
+import "fmt"
+
+// CivilianBuilder builds a Civilian with chained setters.
+// Values are checked when Build is called.
+type CivilianBuilder struct {
+	p Civilian
+}
+
+// NewCivilianBuilder returns a builder for an empty, loyal, tech 0 Civilian
+// living in an open colony.
+func NewCivilianBuilder() *CivilianBuilder {
+	return &CivilianBuilder{}
+}
+
+// Build returns the Civilian or an error if any of the values are invalid.
+func (b *CivilianBuilder) Build() (Civilian, error) {
+	if err := b.p.Validate(); err != nil {
+		return Civilian{}, fmt.Errorf("build civilian: %w", err)
+	}
+	return b.p, nil
+}
+
+// ColonyKind sets the kind of colony the population lives in.
+func (b *CivilianBuilder) ColonyKind(kind ColonyKind) *CivilianBuilder {
+	b.p.kind = kind
+	return b
+}
+
+// Loyal sets the number of loyal citizens.
+func (b *CivilianBuilder) Loyal(qty int) *CivilianBuilder {
+	b.p.qty.loyal = qty
+	return b
+}
+
+// OnShip sets whether the population is on a ship.
+func (b *CivilianBuilder) OnShip(onShip bool) *CivilianBuilder {
+	b.p.onShip = onShip
+	return b
+}
+
+// Rebel sets the number of rebel citizens.
+func (b *CivilianBuilder) Rebel(qty int) *CivilianBuilder {
+	b.p.qty.rebel = qty
+	return b
+}
+
+// Tech sets the tech level.
+func (b *CivilianBuilder) Tech(techLevel int) *CivilianBuilder {
+	b.p.techLevel = techLevel
+	return b
+}
@@ -0,0 +1,59 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge_test
+
+import (
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestCivilianBuilder(t *testing.T) {
+	p, err := wge.NewCivilianBuilder().Loyal(900).Rebel(100).Tech(4).ColonyKind(wge.ResortColony).Build()
+	if err != nil {
+		t.Fatalf("builder: expected nil, got %v\n", err)
+	}
+	if p.Population() != 1_000 || p.Rebels() != 100 || p.TechLevel() != 4 {
+		t.Errorf("builder: expected 1000/100/4, got %d/%d/%d\n", p.Population(), p.Rebels(), p.TechLevel())
+	}
+	if !p.IsResortColony() || !p.IsOnOpenColony() || p.IsOnShip() {
+		t.Errorf("builder: expected resort colony, got resort %v, open %v, ship %v\n", p.IsResortColony(), p.IsOnOpenColony(), p.IsOnShip())
+	}
+
+	ship, err := wge.NewCivilianBuilder().Loyal(50).Tech(10).OnShip(true).Build()
+	if err != nil {
+		t.Fatalf("builder: ship: expected nil, got %v\n", err)
+	}
+	if !ship.IsOnShip() || !ship.IsOnLifeSupport() || ship.IsResortColony() {
+		t.Errorf("builder: ship: expected ship on life support, got ship %v, life support %v\n", ship.IsOnShip(), ship.IsOnLifeSupport())
+	}
+
+	// invalid values are reported when building
+	for _, tc := range []struct {
+		id int
+		b  *wge.CivilianBuilder
+	}{
+		{1, wge.NewCivilianBuilder().Loyal(-1)},
+		{2, wge.NewCivilianBuilder().Rebel(-1)},
+		{3, wge.NewCivilianBuilder().Tech(11)},
+		{4, wge.NewCivilianBuilder().ColonyKind(wge.ColonyKind(42))},
+	} {
+		if _, err := tc.b.Build(); err == nil {
+			t.Errorf("builder: %d: expected error, got nil\n", tc.id)
+		}
+	}
+}
@@ -18,8 +18,53 @@ package wge
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// flags used in the packed binary format
+const (
+	binaryOnShip   byte = 1 << 4
+	binaryResidual byte = 1 << 5
+	binaryFactions byte = 1 << 6
+	// binaryExtended means that an extension byte follows the factions.
+	binaryExtended byte = 1 << 7
+)
+
+// flags used in the extension byte of the packed binary format.
+// Each flag means that its field follows, in this order.
+const (
+	binaryFounded  byte = 1 << 0
+	binaryHistory  byte = 1 << 1
+	binaryResearch byte = 1 << 2
+	binaryRadical  byte = 1 << 3
+	binaryGarrison byte = 1 << 4
+	binaryFrozen   byte = 1 << 5 // no field follows
+	// binaryExtensions is every flag the decoder understands.
+	binaryExtensions = binaryFounded | binaryHistory | binaryResearch | binaryRadical | binaryGarrison | binaryFrozen
+)
+
+// protobuf wire types used by the proto format
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// birthHistoryTurns is the number of turns of births a Civilian remembers.
+const birthHistoryTurns = 8
+
+// compile time checks that Civilian implements the interfaces
+var (
+	_ Aged            = Civilian{}
+	_ PopulationGroup = Civilian{}
+	_ Reproducer      = Civilian{}
+	_ TechLevel       = Civilian{}
+	_ Unit            = Civilian{}
 )
 
 // Civilian is a population unit composed of the bourgeoisie, retirees,
@@ -28,17 +73,72 @@ import (
 type Civilian struct {
 	qty struct {
 		loyal int
-		rebel int
+		rebel int // rebels in the default faction
 	}
+	factions  factions // named rebel factions
 	techLevel int
+	kind      ColonyKind
+	env       Environment
+	onShip    bool
+	frozen    bool // frozen units skip simulation
+	founded   int  // turn the unit was founded
+	// residual holds the fractions of a person left over from earlier turns.
+	// They are carried forward so that tiny colonies still grow (or die out).
+	residual struct {
+		births float64
+		deaths float64
+	}
+	// births is the number of people born in each recent turn, newest
+	// first. It is an array so that units stay comparable values.
+	births [birthHistoryTurns]int
+	// research is the number of research points toward the next tech level.
+	research float64
+	// radical is how hardened the rebels are, from 0 to 1.
+	radical float64
+	// garrison is the number of loyal citizens pinned in place. It is a
+	// subset of the loyal citizens that never migrates or rebels.
+	garrison int
 }
 
 // auxCivilian is a helper to convert to/from json.
 // used to implement json.Marshaler and json.Unmarshaler interfaces.
 type auxCivilian struct {
-	LoyalCitizens int `json:"loyal-citizens"`
-	RebelCitizens int `json:"rebel-citizens"`
-	TechLevel     int `json:"tech-level"`
+	LoyalCitizens int            `json:"loyal-citizens"`
+	RebelCitizens int            `json:"rebel-citizens"`
+	Factions      map[string]int `json:"factions,omitempty"`
+	TechLevel     int            `json:"tech-level"`
+	ColonyKind    ColonyKind     `json:"colony-kind,omitempty"`
+	Environment   Environment    `json:"environment,omitempty"`
+	OnShip        bool           `json:"on-ship,omitempty"`
+	Frozen        bool           `json:"frozen,omitempty"`
+	FoundedTurn   int            `json:"founded-turn,omitempty"`
+	BirthResidual float64        `json:"birth-residual,omitempty"`
+	RecentBirths  []int          `json:"recent-births,omitempty"`
+	Research      float64        `json:"research-points,omitempty"`
+	Radical       float64        `json:"radicalization,omitempty"`
+	Garrison      int            `json:"garrison,omitempty"`
+	DeathResidual float64        `json:"death-residual,omitempty"`
+}
+
+// auxCivilianAliases is a helper to load data written with older field names.
+// Pointers are used to tell missing fields from fields set to zero.
+type auxCivilianAliases struct {
+	LoyalCitizens *int `json:"loyal-citizens"`
+	RebelCitizens *int `json:"rebel-citizens"`
+	Loyal         *int `json:"loyal"` // deprecated: use loyal-citizens
+	Rebel         *int `json:"rebel"` // deprecated: use rebel-citizens
+}
+
+// CivilianDelta is the signed change between two states of a Civilian.
+// Rebel is the change in the total of all factions.
+//
+// Births, deaths, and migration are not recorded on the unit, so a delta
+// can't separate them; Population is their net effect.
+type CivilianDelta struct {
+	Loyal      int
+	Rebel      int
+	Tech       int
+	Population int
 }
 
 func NewCivilian(pop, techLevel int) Civilian {
@@ -48,19 +148,648 @@ func NewCivilian(pop, techLevel int) Civilian {
 	return p
 }
 
+// ApplyTurnBatch advances each unit by one turn with the same standard of
+// living and percent capacity, returning the results in a new slice.
+// Rates are computed once for each combination of tech level and location
+// rather than once per unit. Results are the same as calling ApplyTurn on
+// each unit.
+func ApplyTurnBatch(units []Civilian, standardOfLiving, pctCapacity float64) []Civilian {
+	// rates are cached by tech level and by location (open, ship, resort)
+	type rates struct {
+		ok           bool
+		birth, death float64
+	}
+	var cache [11][3]rates
+	results := make([]Civilian, len(units))
+	for i, p := range units {
+		if p.IsExtinct() || !(0 <= p.techLevel && p.techLevel <= 10) {
+			results[i] = p.ApplyTurn(standardOfLiving, pctCapacity)
+			continue
+		}
+		location := 0
+		if p.IsOnShip() {
+			location = 1
+		} else if p.IsResortColony() {
+			location = 2
+		}
+		r := &cache[p.techLevel][location]
+		if !r.ok {
+			r.ok = true
+			r.birth = p.NaturalBirthRate(standardOfLiving, pctCapacity)
+			r.death = p.NaturalDeathRate(standardOfLiving, pctCapacity)
+		}
+		results[i] = p.applyRates(r.birth, r.death, 0, math.MaxInt)
+	}
+	return results
+}
+
+// CivilianFromHeadcount returns a Civilian from raw counts of people.
+// Units store people, not the 100-person units used by Quantity, so the
+// counts are not scaled. people is the total headcount, including the
+// rebels. rebels is limited to the range 0 to people.
+func CivilianFromHeadcount(people, rebels, techLevel int) Civilian {
+	if people < 0 {
+		people = 0
+	}
+	if rebels < 0 {
+		rebels = 0
+	} else if rebels > people {
+		rebels = people
+	}
+	var p Civilian
+	p.qty.loyal, p.qty.rebel = people-rebels, rebels
+	p.techLevel = techLevel
+	return p
+}
+
+// CivilianFromMap returns a Civilian from a map that uses the same field
+// names as the json format. It is meant for scripting layers that pass
+// data as maps of strings.
+//
+// Counts and the tech level may be numbers or numeric strings, residuals
+// may be numbers or strings, on-ship may be a bool or a string, and the
+// colony kind and environment must be strings. The loyal-citizens,
+// rebel-citizens, and tech-level fields are required. Factions may be a
+// map of names to counts. The result is checked with Validate.
+func CivilianFromMap(m map[string]any) (Civilian, error) {
+	var aux auxCivilian
+	for _, field := range []struct {
+		name string
+		ptr  *int
+	}{
+		{"loyal-citizens", &aux.LoyalCitizens},
+		{"rebel-citizens", &aux.RebelCitizens},
+		{"tech-level", &aux.TechLevel},
+	} {
+		v, ok := m[field.name]
+		if !ok {
+			return Civilian{}, fmt.Errorf("civilian from map: %s: missing", field.name)
+		}
+		n, err := asInt(v)
+		if err != nil {
+			return Civilian{}, fmt.Errorf("civilian from map: %s: %w", field.name, err)
+		}
+		*field.ptr = n
+	}
+	if v, ok := m["founded-turn"]; ok {
+		n, err := asInt(v)
+		if err != nil {
+			return Civilian{}, fmt.Errorf("civilian from map: founded-turn: %w", err)
+		}
+		aux.FoundedTurn = n
+	}
+	for _, field := range []struct {
+		name string
+		ptr  *float64
+	}{
+		{"birth-residual", &aux.BirthResidual},
+		{"death-residual", &aux.DeathResidual},
+		{"research-points", &aux.Research},
+		{"radicalization", &aux.Radical},
+	} {
+		if v, ok := m[field.name]; ok {
+			f, err := asFloat(v)
+			if err != nil {
+				return Civilian{}, fmt.Errorf("civilian from map: %s: %w", field.name, err)
+			}
+			*field.ptr = f
+		}
+	}
+	if v, ok := m["garrison"]; ok {
+		n, err := asInt(v)
+		if err != nil {
+			return Civilian{}, fmt.Errorf("civilian from map: garrison: %w", err)
+		}
+		aux.Garrison = n
+	}
+	if v, ok := m["frozen"]; ok {
+		b, err := asBool(v)
+		if err != nil {
+			return Civilian{}, fmt.Errorf("civilian from map: frozen: %w", err)
+		}
+		aux.Frozen = b
+	}
+	if v, ok := m["on-ship"]; ok {
+		b, err := asBool(v)
+		if err != nil {
+			return Civilian{}, fmt.Errorf("civilian from map: on-ship: %w", err)
+		}
+		aux.OnShip = b
+	}
+	if v, ok := m["colony-kind"]; ok {
+		if err := aux.ColonyKind.UnmarshalText([]byte(fmt.Sprint(v))); err != nil {
+			return Civilian{}, fmt.Errorf("civilian from map: colony-kind: %w", err)
+		}
+	}
+	if v, ok := m["environment"]; ok {
+		if err := aux.Environment.UnmarshalText([]byte(fmt.Sprint(v))); err != nil {
+			return Civilian{}, fmt.Errorf("civilian from map: environment: %w", err)
+		}
+	}
+	if v, ok := m["factions"]; ok {
+		named, ok := v.(map[string]any)
+		if !ok {
+			return Civilian{}, fmt.Errorf("civilian from map: factions: can't convert %T to map", v)
+		}
+		aux.Factions = map[string]int{}
+		for name, v := range named {
+			n, err := asInt(v)
+			if err != nil {
+				return Civilian{}, fmt.Errorf("civilian from map: factions: %s: %w", name, err)
+			}
+			aux.Factions[name] = n
+		}
+	}
+	if v, ok := m["recent-births"]; ok {
+		var list []any
+		switch v := v.(type) {
+		case []int:
+			for _, n := range v {
+				list = append(list, n)
+			}
+		case []any:
+			list = v
+		default:
+			return Civilian{}, fmt.Errorf("civilian from map: recent-births: can't convert %T to list", v)
+		}
+		for _, v := range list {
+			n, err := asInt(v)
+			if err != nil {
+				return Civilian{}, fmt.Errorf("civilian from map: recent-births: %w", err)
+			}
+			aux.RecentBirths = append(aux.RecentBirths, n)
+		}
+	}
+	p, err := fromAux(aux)
+	if err == nil {
+		err = p.Validate()
+	}
+	if err != nil {
+		return Civilian{}, fmt.Errorf("civilian from map: %w", err)
+	}
+	return p, nil
+}
+
+// DecodeCivilianRepaired decodes a json Civilian without rejecting values
+// that are out of range. The unit is passed through Repair, and the list
+// of changes is returned so that callers can load third-party data and
+// warn about it. It still returns an error for data that isn't json.
+func DecodeCivilianRepaired(data []byte) (Civilian, []string, error) {
+	aux, err := decodeAuxCivilian(data)
+	if err != nil {
+		return Civilian{}, nil, fmt.Errorf("decode civilian: %w", err)
+	}
+	p, err := fromAux(aux)
+	if err != nil {
+		return Civilian{}, nil, fmt.Errorf("decode civilian: %w", err)
+	}
+	p, changes := p.Repair()
+	return p, changes, nil
+}
+
+// Diff returns the change from before to after, for turn reports such as
+// "your colony gained 412 loyal citizens and lost 37 to unrest."
+func Diff(before, after Civilian) CivilianDelta {
+	return CivilianDelta{
+		Loyal:      after.qty.loyal - before.qty.loyal,
+		Rebel:      after.Rebels() - before.Rebels(),
+		Tech:       after.techLevel - before.techLevel,
+		Population: after.Population() - before.Population(),
+	}
+}
+
+// DistributeDeaths splits deaths between loyal and rebel citizens.
+//
+// The policy is deterministic: rebels die in proportion to their share of
+// the population, rounded down, and the remainder is assigned to the loyal
+// citizens. Deaths are limited to the population.
+func DistributeDeaths(loyal, rebel, deaths int) (loyalDeaths, rebelDeaths int) {
+	return distributeDeaths(loyal, rebel, deaths, 0)
+}
+
+// MergeAll combines any number of population units into one.
+//
+// MergeAll is not the same as folding Merge over the units. The blended
+// tech level is computed once as the weighted average over the total
+// population, and discontent is applied once: each unit that loses tech
+// levels turns rebel*deltaTech/100 loyal citizens into rebels, with a
+// minimum of one for the whole merge. The result does not depend on the
+// order of the units, except that the merged unit takes the location of
+// the first unit with a non-zero population. Named rebel factions are
+// merged by name, and new rebels join the default faction. The merged
+// unit keeps the earliest founding turn.
+func MergeAll(units ...Civilian) Civilian {
+	return MergeAllWith(defaultRateConfig, units...)
+}
+
+// MergeAllWith is MergeAll using the discontent settings in the config,
+// including MergeLossless. As with MergeWith, the new rebels are limited
+// to the loyal citizens outside the garrison.
+func MergeAllWith(cfg RateConfig, units ...Civilian) Civilian {
+	var n Civilian
+	var members []Civilian
+	for _, u := range units {
+		if u.Population() != 0 {
+			members = append(members, u)
+		}
+	}
+	if len(members) == 0 {
+		return n
+	} else if len(members) == 1 {
+		return members[0]
+	}
+
+	n.kind, n.env, n.onShip = members[0].kind, members[0].env, members[0].onShip
+	n.founded = members[0].founded
+	totalTech, totalResearch, totalRadical := 0, 0.0, 0.0
+	for _, u := range members {
+		if u.founded < n.founded {
+			n.founded = u.founded
+		}
+		n.qty.loyal, n.qty.rebel = n.qty.loyal+u.qty.loyal, n.qty.rebel+u.qty.rebel
+		n.garrison += u.garrison
+		n = n.mergeFactions(u.factions)
+		n.residual.births, n.residual.deaths = n.residual.births+u.residual.births, n.residual.deaths+u.residual.deaths
+		for turn := range n.births {
+			n.births[turn] += u.births[turn]
+		}
+		totalTech += u.Population() * u.techLevel
+		totalResearch += float64(u.Population()) * u.research
+		totalRadical += float64(u.Rebels()) * u.radical
+	}
+	n.techLevel = totalTech / n.Population()
+	n.research = totalResearch / float64(n.Population())
+	if rebels := n.Rebels(); rebels > 0 {
+		n.radical = totalRadical / float64(rebels)
+	}
+
+	deltaRebels := 0 // merging units always increases discontent
+	sameTech := true
+	for _, u := range members {
+		if u.techLevel != members[0].techLevel {
+			sameTech = false
+		}
+		if n.techLevel < u.techLevel {
+			deltaTech := u.techLevel - n.techLevel
+			deltaRebels += cfg.mergeDiscontent(u.Rebels(), deltaTech)
+		}
+	}
+	if minRebels := cfg.mergeMinRebels(sameTech, n.Rebels() == 0); deltaRebels < minRebels {
+		deltaRebels = minRebels
+	}
+	if deltaRebels > n.qty.loyal-n.garrison { // the garrison never rebels
+		deltaRebels = n.qty.loyal - n.garrison
+	}
+	n.qty.loyal, n.qty.rebel = n.qty.loyal-deltaRebels, n.qty.rebel+deltaRebels
+
+	return n
+}
+
+// Age implements the Aged interface.
+func (p Civilian) Age(currentTurn int) int {
+	return age(p.founded, currentTurn)
+}
+
+// AgePyramid returns a rough age distribution of the population as three
+// cohorts: young, working, and old. The cohorts add up to the population.
+//
+// The unit doesn't track ages, so the pyramid is estimated from the births
+// in the last eight turns and the base death rate d for the tech level.
+// The young are the recent births that are still alive, assuming each
+// cohort lost d of its members every turn, limited to the population.
+// The rest are adults. Using the age distribution of a population where
+// everyone dies at the rate d, the share of adults older than the life
+// expectancy of 1/d turns, about 37%, are old.
+func (p Civilian) AgePyramid() []int {
+	pop := p.Population()
+	d := defaultRateConfig.DeathBase[clampTechLevel(p.techLevel)]
+	young := 0.0
+	for turn, births := range p.births {
+		young += float64(births) * math.Pow(1-d, float64(turn))
+	}
+	youngCohort := int(young)
+	if youngCohort > pop {
+		youngCohort = pop
+	}
+	adults := pop - youngCohort
+	old := int(float64(adults) * math.Pow(1-d, 1/d))
+	return []int{youngCohort, adults - old, old}
+}
+
+// ApplyTurn returns the population after one turn of natural births and deaths.
+// Both are calculated from the population at the start of the turn and are
+// truncated to whole people. The fractions left over are kept in a residual
+// and added to the next turn, so a colony gaining 0.3 people per turn grows
+// by one person every few turns. Births are added to the loyal citizens.
+// Deaths are split between loyal and rebel citizens by DistributeDeaths:
+// proportionally, with the remainder assigned to the loyal citizens.
+// An extinct population is never changed.
+func (p Civilian) ApplyTurn(standardOfLiving, pctCapacity float64) Civilian {
+	if p.IsExtinct() {
+		return p
+	}
+	return p.applyRates(p.NaturalBirthRate(standardOfLiving, pctCapacity), p.NaturalDeathRate(standardOfLiving, pctCapacity), 0, math.MaxInt)
+}
+
+// ApplyCrisis returns the population after a turn-over-turn change in the
+// standard of living. A sudden collapse turns loyal citizens into rebels.
+//
+// The fraction of loyal citizens that defect is half the square of the
+// relative drop in the standard. A 10% decline converts 0.5% of the loyal
+// citizens, while a crash from 1.0 to 0.2 converts 32% of them.
+// A steady or rising standard causes no defections, and the garrison
+// never defects. A frozen unit doesn't notice the crisis.
+func (p Civilian) ApplyCrisis(priorStandard, currentStandard float64) Civilian {
+	if p.frozen || priorStandard <= 0 || currentStandard >= priorStandard {
+		return p
+	}
+	drop := clamp((priorStandard-currentStandard)/priorStandard, 0, 1)
+	defectors := int(float64(p.qty.loyal-p.garrison) * 0.5 * drop * drop)
+	p.qty.loyal, p.qty.rebel = p.qty.loyal-defectors, p.qty.rebel+defectors
+	return p
+}
+
+// ApplyEpidemic returns the population after an outbreak of disease, along with
+// the number of people killed. The deaths are in addition to natural deaths.
+//
+// Severity ranges from 0 (no outbreak) to 1 (the worst plague) and is the
+// fraction of the population killed at tech 0 in a half-full colony.
+// Each tech level reduces the toll by 5% (tech 10 suffers half the deaths),
+// and crowding raises it: the multiplier is 0.5 plus the percent capacity,
+// so a full colony suffers 1.5 times the deaths and a packed ship more.
+// No more than 75% of the population dies from a single outbreak, and a
+// frozen unit loses no one.
+func (p Civilian) ApplyEpidemic(severity, pctCapacity float64) (Civilian, int) {
+	if p.frozen {
+		return p, 0
+	}
+	severity = clamp(severity, 0, 1)
+	pctCapacity = clamp(pctCapacity, 0, 2)
+	resistance := 1 - 0.05*float64(clampTechLevel(p.techLevel))
+	rate := clamp(severity*resistance*(0.5+pctCapacity), 0, 0.75)
+	deaths := int(float64(p.Population()) * rate)
+	return p.kill(deaths, 0), deaths
+}
+
+// ApplyFoodShortage returns the population after a turn with only
+// foodAvailable FOOD units, along with the number of people who starved.
+// The deaths are in addition to natural deaths. The people who go unfed
+// are the population times the fraction of the FoodNeeded that is
+// missing, and half of them, rounded up, starve. A fed population, an
+// extinct one, or a frozen one loses no one.
+func (p Civilian) ApplyFoodShortage(foodAvailable float64) (Civilian, int) {
+	needed := p.FoodNeeded()
+	if p.frozen || needed <= 0 || foodAvailable >= needed {
+		return p, 0
+	}
+	unfed := float64(p.Population()) * (1 - clamp(foodAvailable/needed, 0, 1))
+	deaths := int(math.Ceil(unfed*0.5 - 1e-9))
+	if pop := p.Population(); deaths > pop {
+		deaths = pop
+	}
+	return p.kill(deaths, 0), deaths
+}
+
+// ApplyResearch returns the population after adding research points
+// toward the next tech level. When the points reach the threshold for the
+// current tech level, the unit advances one level and the points are
+// reset to zero; points past the threshold are lost. The threshold is
+// ResearchThreshold. Research stops at tech 10, and points that are not
+// positive are ignored, as is research by a frozen unit.
+func (p Civilian) ApplyResearch(researchPoints float64) Civilian {
+	if p.frozen || !(researchPoints > 0) || p.techLevel >= 10 {
+		return p
+	}
+	p.research += researchPoints
+	if p.research >= p.ResearchThreshold() {
+		p.techLevel, p.research = p.techLevel+1, 0
+	}
+	return p
+}
+
+// ApplyTurnAt is ApplyTurn for the given game turn, with a grace period
+// for new units: the death rate is DeathRateAt for the age of the unit,
+// so the overcrowding deaths are softened for GraceTurns turns (five by
+// default) after the unit is founded. The birth rate is not changed. A
+// founding turn of 0 means the unit predates founding turns, as with
+// legacy data, so those units get no grace, and neither do units whose
+// founding turn is after currentTurn.
+func (p Civilian) ApplyTurnAt(currentTurn int, standardOfLiving, pctCapacity float64) Civilian {
+	if p.IsExtinct() {
+		return p
+	}
+	death := p.NaturalDeathRate(standardOfLiving, pctCapacity)
+	if p.founded != 0 {
+		death = DeathRateAt(defaultRateConfig, p.techLevel, standardOfLiving, pctCapacity, currentTurn-p.founded)
+	}
+	return p.applyRates(p.NaturalBirthRate(standardOfLiving, pctCapacity), death, 0, math.MaxInt)
+}
+
+// ApplyTurnBiased is ApplyTurn with natural deaths skewed toward the
+// rebels. The bias is clamped to 0 to 1. At 0, deaths are split as in
+// ApplyTurn. At 1, rebels die first and loyal citizens only die when
+// there are no rebels left. In between, the rebel deaths are moved that
+// fraction of the way from their proportional share to the most rebels
+// that can die. The number of deaths is the same for every bias.
+func (p Civilian) ApplyTurnBiased(standardOfLiving, pctCapacity, rebelDeathBias float64) Civilian {
+	if p.IsExtinct() {
+		return p
+	}
+	return p.applyRates(p.NaturalBirthRate(standardOfLiving, pctCapacity), p.NaturalDeathRate(standardOfLiving, pctCapacity), rebelDeathBias, math.MaxInt)
+}
+
+// ApplyTurnCapped is ApplyTurn on a world with an absolute limit on its
+// population, such as a biological cap that no amount of building can
+// raise. Deaths apply as usual, but births are limited to the room left
+// under maxPopulation after the deaths, so a unit at the cap only
+// replaces its dead. Births that would pass the cap are dropped along
+// with the birth residual.
+//
+// The cap is separate from pctCapacity. Percent capacity still sets the
+// birth and death rates, so a unit can be well under the capacity of its
+// colony, with a high birth rate, and still not grow because it is at
+// the cap. A unit over the cap has no births and shrinks by its deaths.
+func (p Civilian) ApplyTurnCapped(standardOfLiving, pctCapacity float64, maxPopulation int) Civilian {
+	if p.IsExtinct() {
+		return p
+	}
+	return p.applyRates(p.NaturalBirthRate(standardOfLiving, pctCapacity), p.NaturalDeathRate(standardOfLiving, pctCapacity), 0, maxPopulation)
+}
+
+// ApplyUnrest returns the population after one turn of unrest. Rebels
+// that are left with their grievances harden: while the Discontent at the
+// standard of living and tax rate is 0.5 or more, the radicalization rises
+// by 0.1 each turn, up to 1. Once discontent falls below 0.5, 5% of the
+// rebels drift back to loyalty each turn and the radicalization eases by
+// 0.05, down to 0. Radicalization slows the drift in proportion, as it
+// does Suppress, so fully radicalized rebels never drift back. The
+// drifters are taken from the factions as with deaths. A frozen unit is
+// unchanged.
+func (p Civilian) ApplyUnrest(standardOfLiving, taxRate float64) Civilian {
+	const threshold, gain, decay, drift = 0.5, 0.1, 0.05, 0.05
+	if p.frozen {
+		return p
+	}
+	if p.Discontent(standardOfLiving, taxRate) >= threshold {
+		p.radical = clamp(p.radical+gain, 0, 1)
+		return p
+	}
+	if drifters := int(float64(p.Rebels()) * drift * (1 - p.radical)); drifters > 0 {
+		p = p.killRebels(drifters)
+		p.qty.loyal += drifters
+	}
+	p.radical = clamp(p.radical-decay, 0, 1)
+	return p
+}
+
+// Clone returns a deep copy of the population.
+// Civilian holds no references, so the copy shares nothing with p.
+func (p Civilian) Clone() Civilian {
+	return p
+}
+
 // Code implements the Unit interface.
 func (p Civilian) Code() string {
 	return "CIV"
 }
 
-// FoodNeeded implements the PopulationGroup interface
+// Conscript returns the population after qty loyal citizens are drafted,
+// along with a Soldier unit holding the recruits. The soldiers keep the
+// tech level and location of the population. Rebels can't be drafted and
+// the garrison is already under arms, so it returns an error if qty is
+// negative or more than the other loyal citizens.
+//
+// The draft is unpopular with the families left behind: one loyal citizen
+// turns rebel for every 20 recruits, truncated. The garrison never defects.
+func (p Civilian) Conscript(qty int) (remaining Civilian, soldiers Soldier, err error) {
+	const defectorsPerRecruit = 0.05
+	if available := p.qty.loyal - p.garrison; qty < 0 || qty > available {
+		return p, Soldier{}, fmt.Errorf("conscript: %d: must be 0 to %d", qty, available)
+	}
+	soldiers = NewSoldier(qty, p.techLevel)
+	soldiers.kind, soldiers.env, soldiers.onShip = p.kind, p.env, p.onShip
+	p.qty.loyal -= qty
+	defectors := int(float64(qty) * defectorsPerRecruit)
+	if available := p.qty.loyal - p.garrison; defectors > available {
+		defectors = available
+	}
+	p.qty.loyal, p.qty.rebel = p.qty.loyal-defectors, p.qty.rebel+defectors
+	return p, soldiers, nil
+}
+
+// Describe implements the Unit interface.
+func (p Civilian) Describe() UnitDescription {
+	return describe(p)
+}
+
+// Discontent returns the fraction of the population that is unhappy with
+// the government, from 0 to 1. It starts at the fraction of rebels and
+// rises with the tax rate divided by the standard of living, so a
+// prosperous population tolerates higher taxes than a poor one.
+func (p Civilian) Discontent(standardOfLiving, taxRate float64) float64 {
+	pop := p.Population()
+	if pop == 0 {
+		return 0
+	}
+	rebelFraction := float64(p.Rebels()) / float64(pop)
+	return clamp(rebelFraction+clamp(taxRate, 0, 1)/floorStandard(standardOfLiving), 0, 1)
+}
+
+// Equal returns true if the two units have the same state.
+// The birth history is not compared. It only feeds reports such as
+// AgePyramid and it moves along every turn, so a unit whose births
+// match its deaths is unchanged by a turn. The residuals are compared
+// with isClose, since rates like 1% can't be stored exactly and leave
+// a trace of floating point noise behind each turn.
+func (p Civilian) Equal(q Civilian) bool {
+	if !isClose(p.residual.births, q.residual.births) || !isClose(p.residual.deaths, q.residual.deaths) {
+		return false
+	}
+	p.residual, p.births = q.residual, q.births
+	return p == q
+}
+
+// Faction returns the number of rebels in the named faction.
+// The empty name is the default faction, which holds the rebels that
+// don't belong to a named faction.
+func (p Civilian) Faction(name string) int {
+	if name == "" {
+		return p.qty.rebel
+	}
+	return p.factions.get(name)
+}
+
+// Factions returns the named rebel factions, sorted by name.
+// The default faction is not included.
+func (p Civilian) Factions() []Faction {
+	return p.factions.list()
+}
+
+// Federate combines two population units without merging their tech
+// levels. See MultiTechPopulation.
+func (p Civilian) Federate(q Civilian) MultiTechPopulation {
+	return MultiTechPopulation{}.Federate(p).Federate(q)
+}
+
+// Fingerprint returns a hash of the loyal, rebel, and tech level fields.
+// It is computed with 64-bit FNV-1a over the fields encoded as little-endian
+// 64-bit integers, so it is stable across runs and platforms.
+// The rebel field is the total of all factions; when there are named
+// factions, each name and count is hashed after the tech level.
+// Equal units have the same fingerprint.
+func (p Civilian) Fingerprint() uint64 {
+	var buf [24]byte
+	binary.LittleEndian.PutUint64(buf[0:], uint64(int64(p.qty.loyal)))
+	binary.LittleEndian.PutUint64(buf[8:], uint64(int64(p.Rebels())))
+	binary.LittleEndian.PutUint64(buf[16:], uint64(int64(p.techLevel)))
+	h := fnv.New64a()
+	_, _ = h.Write(buf[:])
+	for _, faction := range p.factions.list() {
+		_, _ = h.Write([]byte(faction.Name))
+		binary.LittleEndian.PutUint64(buf[0:], uint64(int64(faction.Rebels)))
+		_, _ = h.Write(buf[:8])
+	}
+	return h.Sum64()
+}
+
+// FoodNeeded implements the PopulationGroup interface.
+// Demand is 0.0125 per 100 people at tech 5 and is scaled by tech level.
 func (p Civilian) FoodNeeded() float64 {
-	return float64(p.qty.loyal+p.qty.rebel) * 0.01 * 0.0125
+	return float64(p.Population()) * 0.01 * 0.0125 * techFoodFactor(p.techLevel)
+}
+
+// FoundedTurn implements the Aged interface.
+func (p Civilian) FoundedTurn() int {
+	return p.founded
+}
+
+// Garrison returns the number of loyal citizens pinned as a garrison.
+func (p Civilian) Garrison() int {
+	return p.garrison
+}
+
+// Headcount returns the number of loyal and rebel people in the unit.
+// It is the inverse of CivilianFromHeadcount; divide the total by 100
+// to get the Quantity.
+func (p Civilian) Headcount() (loyal, rebel int) {
+	return p.qty.loyal, p.Rebels()
+}
+
+// IsExtinct returns true if the population has died out.
+// An extinct unit is a terminal state; it keeps its tech level
+// but never grows, and merging with it returns the other unit.
+func (p Civilian) IsExtinct() bool {
+	return p.Population() == 0
+}
+
+// IsFrozen returns true if the unit is frozen and skips simulation.
+func (p Civilian) IsFrozen() bool {
+	return p.frozen
 }
 
 // IsOnClosedColony returns true if the population is on a closed colony.
 func (p Civilian) IsOnClosedColony() bool {
-	return false
+	return !p.onShip && p.kind == ClosedColony
 }
 
 // IsOnLifeSupport returns true if the population depends on life support for survival.
@@ -70,51 +799,259 @@ func (p Civilian) IsOnLifeSupport() bool {
 }
 
 // IsOnOpenColony returns true if the population is on an open colony.
+// Resort colonies are open colonies.
 func (p Civilian) IsOnOpenColony() bool {
-	return false
+	return !p.onShip && p.kind != ClosedColony
 }
 
 // IsOnShip returns true if the population is on a ship.
 func (p Civilian) IsOnShip() bool {
-	return false
+	return p.onShip
 }
 
 // IsResortColony returns true if the population is in a resort colony
 func (p Civilian) IsResortColony() bool {
-	return false
+	return !p.onShip && p.kind == ResortColony
 }
 
-// LifeSupportNeeded implements the PopulationGroup interface
+// LifeSupportNeeded implements the PopulationGroup interface.
+// Demand is 0.5 per 100 people at tech 5 and is scaled by tech level.
+// Closed colonies are also scaled by the environment of the planet.
+// Populations that are not on life support need none.
 func (p Civilian) LifeSupportNeeded() float64 {
-	return float64(p.qty.loyal+p.qty.rebel) * 0.01 * 0.5
+	if !p.IsOnLifeSupport() {
+		return 0
+	}
+	return float64(p.Population()) * 0.01 * 0.5 * techLifeSupportFactor(p.techLevel) * lifeSupportFactor(p.onShip, p.env)
+}
+
+// kill removes deaths from the population. They are split between loyal
+// and rebel citizens as in DistributeDeaths, skewed toward the rebels by
+// the bias.
+func (p Civilian) kill(deaths int, rebelDeathBias float64) Civilian {
+	loyalDeaths, rebelDeaths := distributeDeaths(p.qty.loyal, p.Rebels(), deaths, rebelDeathBias)
+	p.qty.loyal -= loyalDeaths
+	if p.garrison > p.qty.loyal { // the garrison dies last
+		p.garrison = p.qty.loyal
+	}
+	return p.killRebels(rebelDeaths)
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+//
+// The packed format is the loyal and rebel counts as unsigned varints,
+// one byte for the tech level, and one byte of flags holding the colony
+// kind (bits 0-1), the environment (bits 2-3), on-ship (bit 4), whether
+// residuals follow (bit 5), whether factions follow (bit 6), and whether
+// an extension byte follows (bit 7). The birth and death residuals are
+// written as little-endian float64 values only when either is non-zero.
+// Named factions are written as a count byte followed by the length and
+// bytes of each name and the number of rebels as varints.
+//
+// The extension byte is last. Its flags say whether the founding turn
+// follows as a varint (bit 0), the birth history as a count byte and the
+// births as varints, newest first (bit 1), the research points (bit 2)
+// and radicalization (bit 3) as little-endian float64s, and the garrison
+// as a varint (bit 4), in that order; bit 5 marks a frozen unit. Unknown
+// extension flags are an error. Most units pack into a dozen bytes or
+// less.
+func (p Civilian) MarshalBinary() ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("encode civilian: %w", err)
+	}
+	buf := make([]byte, 0, 2*binary.MaxVarintLen64+2+16)
+	buf = binary.AppendUvarint(buf, uint64(p.qty.loyal))
+	buf = binary.AppendUvarint(buf, uint64(p.qty.rebel))
+	buf = append(buf, byte(p.techLevel))
+	flags := byte(p.kind) | byte(p.env)<<2
+	if p.onShip {
+		flags |= binaryOnShip
+	}
+	hasResidual := p.residual.births != 0 || p.residual.deaths != 0
+	if hasResidual {
+		flags |= binaryResidual
+	}
+	named := p.factions.list()
+	if len(named) != 0 {
+		flags |= binaryFactions
+	}
+	var ext byte
+	if p.founded != 0 {
+		ext |= binaryFounded
+	}
+	recent := p.recentBirths()
+	if len(recent) != 0 {
+		ext |= binaryHistory
+	}
+	if p.research != 0 {
+		ext |= binaryResearch
+	}
+	if p.radical != 0 {
+		ext |= binaryRadical
+	}
+	if p.garrison != 0 {
+		ext |= binaryGarrison
+	}
+	if p.frozen {
+		ext |= binaryFrozen
+	}
+	if ext != 0 {
+		flags |= binaryExtended
+	}
+	buf = append(buf, flags)
+	if hasResidual {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(p.residual.births))
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(p.residual.deaths))
+	}
+	if len(named) != 0 {
+		buf = append(buf, byte(len(named)))
+		for _, faction := range named {
+			buf = binary.AppendUvarint(buf, uint64(len(faction.Name)))
+			buf = append(buf, faction.Name...)
+			buf = binary.AppendUvarint(buf, uint64(faction.Rebels))
+		}
+	}
+	if ext == 0 {
+		return buf, nil
+	}
+	buf = append(buf, ext)
+	if p.founded != 0 {
+		buf = binary.AppendVarint(buf, int64(p.founded))
+	}
+	if len(recent) != 0 {
+		buf = append(buf, byte(len(recent)))
+		for _, births := range recent {
+			buf = binary.AppendUvarint(buf, uint64(births))
+		}
+	}
+	if p.research != 0 {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(p.research))
+	}
+	if p.radical != 0 {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(p.radical))
+	}
+	if p.garrison != 0 {
+		buf = binary.AppendUvarint(buf, uint64(p.garrison))
+	}
+	return buf, nil
 }
 
 // MarshalJSON implements the json.Marshaler interface
 func (p Civilian) MarshalJSON() ([]byte, error) {
-	var aux auxCivilian
-	aux.LoyalCitizens = p.qty.loyal
-	aux.RebelCitizens = p.qty.rebel
-	aux.TechLevel = p.techLevel
+	aux := p.toAux()
+	return json.Marshal(&aux)
+}
+
+// MarshalJSONIndent is like MarshalJSON but indents the output as
+// json.MarshalIndent does, for debugging. The fields are the same.
+func (p Civilian) MarshalJSONIndent(prefix, indent string) ([]byte, error) {
+	aux := p.toAux()
+	return json.MarshalIndent(&aux, prefix, indent)
+}
+
+// MarshalJSONVerbose returns the canonical json fields along with read-only
+// computed fields (population, rebel-fraction, food-needed, and
+// life-support-needed) for consumers that can't run the engine.
+// UnmarshalJSON ignores the computed fields.
+func (p Civilian) MarshalJSONVerbose() ([]byte, error) {
+	var aux struct {
+		auxCivilian
+		Population        int     `json:"population"`
+		RebelFraction     float64 `json:"rebel-fraction"`
+		FoodNeeded        float64 `json:"food-needed"`
+		LifeSupportNeeded float64 `json:"life-support-needed"`
+	}
+	aux.auxCivilian = p.toAux()
+	aux.Population = p.Population()
+	if aux.Population != 0 {
+		aux.RebelFraction = float64(p.Rebels()) / float64(aux.Population)
+	}
+	aux.FoodNeeded = p.FoodNeeded()
+	aux.LifeSupportNeeded = p.LifeSupportNeeded()
 	return json.Marshal(&aux)
 }
 
+// MarshalProto returns the population encoded as a protobuf message so
+// that clients in other languages can decode it without Go. The layout is
+//
+//	message Civilian {
+//	  int64 loyal = 1;
+//	  int64 rebel = 2;
+//	  int32 tech  = 3;
+//	}
+//
+// The fields are varints and, as in proto3, fields that are zero are not
+// written. Rebel is the total of all factions. The location, founding
+// turn, factions, and residuals are not part of the message.
+func (p Civilian) MarshalProto() ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("encode civilian: %w", err)
+	}
+	var buf []byte
+	for _, field := range []struct {
+		number int
+		value  int
+	}{
+		{1, p.qty.loyal},
+		{2, p.Rebels()},
+		{3, p.techLevel},
+	} {
+		if field.value != 0 {
+			buf = binary.AppendUvarint(buf, uint64(field.number<<3|protoVarint))
+			buf = binary.AppendUvarint(buf, uint64(field.value))
+		}
+	}
+	return buf, nil
+}
+
 // Mass implements the Unit interface.
+// The mass per unit is 1.00 at tech 5 and is scaled by tech level.
 func (p Civilian) Mass() float64 {
 	const massPerUnit = 1.00 // per 100
-	return p.Quantity() * massPerUnit
+	return p.Quantity() * massPerUnit * techMassFactor(p.techLevel)
 }
 
 // Merge combines two population units.
 // Rebel population and tech levels are calculated as the weighted average of the units.
+// Merging with an extinct unit returns the other unit unchanged.
+// The discontent settings come from the default rate config.
 func (p Civilian) Merge(q Civilian) Civilian {
-	if p.Population() == 0 {
+	return p.MergeWith(q, defaultRateConfig)
+}
+
+// MergeWith is Merge using the discontent settings in the config.
+// The rebels of the unit that loses tech levels recruit
+// MergeTechPenalty of their number per level lost, and at least
+// MergeMinRebels loyal citizens turn rebel, unless MergeLossless is set
+// and both units have the same tech level and no rebels. However harsh the
+// config, no more loyal citizens turn rebel than there are outside the
+// garrison, so the merged unit never has a negative loyal count.
+func (p Civilian) MergeWith(q Civilian, cfg RateConfig) Civilian {
+	if p.IsExtinct() {
 		return q
-	} else if q.Population() == 0 {
+	} else if q.IsExtinct() {
 		return p
 	}
 
 	var n Civilian
+	n.kind, n.env, n.onShip = p.kind, p.env, p.onShip // the merged unit stays where p is
+	n.founded = p.founded                             // and is as old as the older unit
+	if q.founded < n.founded {
+		n.founded = q.founded
+	}
+	n.residual.births, n.residual.deaths = p.residual.births+q.residual.births, p.residual.deaths+q.residual.deaths
+	for turn := range n.births {
+		n.births[turn] = p.births[turn] + q.births[turn]
+	}
 	n.qty.loyal, n.qty.rebel = p.qty.loyal+q.qty.loyal, p.qty.rebel+q.qty.rebel
+	n.garrison = p.garrison + q.garrison
+	n = n.mergeFactions(p.factions).mergeFactions(q.factions)
+	// research points are averaged, weighted by population
+	n.research = (float64(p.Population())*p.research + float64(q.Population())*q.research) / float64(n.Population())
+	// and radicalization is averaged over the rebels
+	if rebels := p.Rebels() + q.Rebels(); rebels > 0 {
+		n.radical = (float64(p.Rebels())*p.radical + float64(q.Rebels())*q.radical) / float64(rebels)
+	}
 	deltaRebels := 0 // merging units always increases discontent
 	if p.techLevel == q.techLevel {
 		n.techLevel = p.techLevel
@@ -124,21 +1061,24 @@ func (p Civilian) Merge(q Civilian) Civilian {
 		// the group losing tech levels gets especially cranky
 		if n.techLevel < p.techLevel {
 			deltaTech := p.techLevel - n.techLevel
-			deltaRebels = p.qty.rebel * deltaTech / 100
+			deltaRebels = cfg.mergeDiscontent(p.Rebels(), deltaTech)
 		} else if n.techLevel < q.techLevel {
 			deltaTech := q.techLevel - n.techLevel
-			deltaRebels = q.qty.rebel * deltaTech / 100
+			deltaRebels = cfg.mergeDiscontent(q.Rebels(), deltaTech)
 		}
 	}
-	if deltaRebels < 1 {
-		deltaRebels = 1
+	if minRebels := cfg.mergeMinRebels(p.techLevel == q.techLevel, n.Rebels() == 0); deltaRebels < minRebels {
+		deltaRebels = minRebels
+	}
+	if deltaRebels > n.qty.loyal-n.garrison { // the garrison never rebels
+		deltaRebels = n.qty.loyal - n.garrison
 	}
 	n.qty.loyal, n.qty.rebel = n.qty.loyal-deltaRebels, n.qty.rebel+deltaRebels
 
 	return n
 }
 
-// NaturalBirthRate implements the PopulationGroup interface.
+// NaturalBirthRate implements the Reproducer interface.
 func (p Civilian) NaturalBirthRate(standardOfLiving, pctCapacity float64) float64 {
 	return naturalBirthRate(p.techLevel, standardOfLiving, pctCapacity, p.IsOnShip(), p.IsResortColony())
 }
@@ -148,20 +1088,288 @@ func (p Civilian) NaturalDeathRate(standardOfLiving, pctCapacity float64) float6
 	return naturalDeathRate(p.techLevel, standardOfLiving, pctCapacity)
 }
 
+// NetGrowthRate returns the natural birth rate minus the natural death rate.
+// It is negative when the population is shrinking.
+func (p Civilian) NetGrowthRate(standardOfLiving, pctCapacity float64) float64 {
+	return p.NaturalBirthRate(standardOfLiving, pctCapacity) - p.NaturalDeathRate(standardOfLiving, pctCapacity)
+}
+
+// Normalize is an optional cleanup step for residual rebels.
+// When the rebels are less than minRebelFraction of the population,
+// they give up and rejoin the loyal citizens. The population is unchanged.
+func (p Civilian) Normalize(minRebelFraction float64) Civilian {
+	pop := p.Population()
+	if pop == 0 || p.Rebels() == 0 {
+		return p
+	}
+	if float64(p.Rebels())/float64(pop) < minRebelFraction {
+		p.qty.loyal, p.qty.rebel = p.qty.loyal+p.Rebels(), 0
+		p.factions = factions{}
+	}
+	return p
+}
+
+// OptimalTaxRate returns the highest tax rate, from 0 to 1, that keeps
+// Discontent below maxDiscontent. Since TaxRevenue grows with the tax rate,
+// this is also the rate that raises the most revenue without revolt.
+// It returns 0 when even an untaxed population is too discontent.
+// This is a planning helper and does not change the unit.
+func (p Civilian) OptimalTaxRate(standardOfLiving float64, maxDiscontent float64) float64 {
+	if p.IsExtinct() || p.Discontent(standardOfLiving, 0) >= maxDiscontent {
+		return 0
+	} else if p.Discontent(standardOfLiving, 1) < maxDiscontent {
+		return 1
+	}
+	lo, hi := 0.0, 1.0 // Discontent(lo) is below the cap, Discontent(hi) is not
+	for i := 0; i < 50; i++ {
+		mid := (lo + hi) / 2
+		if p.Discontent(standardOfLiving, mid) < maxDiscontent {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
 // Population implements the PopulationGroup interface.
 func (p Civilian) Population() int {
-	return p.qty.loyal + p.qty.rebel
+	return p.qty.loyal + p.Rebels()
+}
+
+// Population64 returns the population as an int64 so that totals across
+// many large units can't overflow.
+func (p Civilian) Population64() int64 {
+	total := int64(p.qty.loyal) + int64(p.qty.rebel)
+	for _, faction := range p.factions {
+		total += int64(faction.Rebels)
+	}
+	return total
+}
+
+// ProductivityFactor returns the fraction of normal output the unit
+// produces, from 0 to 1. Rebels withhold their own work and sabotage the
+// work of others, so the factor drops faster as the rebel fraction r
+// rises: it is 1 - r(1+r)/2. An all-loyal unit returns 1.0, 10% rebels
+// return 0.945, 50% rebels return 0.625, and an all-rebel unit returns 0.
+// An extinct unit returns 1.0.
+func (p Civilian) ProductivityFactor() float64 {
+	return productivityFactor(p.Rebels(), p.Population())
+}
+
+// Project returns the population at the end of each of the next turns,
+// assuming the standard of living and percent capacity do not change.
+// It is a forecast and does not change the population.
+func (p Civilian) Project(standardOfLiving, pctCapacity float64, turns int) []int {
+	var series []int
+	for turn := 0; turn < turns; turn++ {
+		p = p.ApplyTurn(standardOfLiving, pctCapacity)
+		series = append(series, p.Population())
+	}
+	return series
 }
 
 // Quantity implements the Unit interface.
+// It is the population in units of 100 people, so 1,250 people are a
+// quantity of 12.5. Use Headcount for the number of people.
 func (p Civilian) Quantity() float64 {
 	// there are 100 people per population unit
-	return float64(p.Population()) * 0.01
+	return float64(p.Population64()) * 0.01
+}
+
+// Radicalization returns how hardened the rebels are, from 0 to 1.
+// It is raised by ApplyUnrest.
+func (p Civilian) Radicalization() float64 {
+	return p.radical
+}
+
+// RateBreakdown returns the base rate, each multiplier, and the final rate
+// for births and deaths, so players can see why a colony is changing.
+// The Factors of each detail label the steps that changed the rate.
+func (p Civilian) RateBreakdown(standardOfLiving, pctCapacity float64) RateBreakdown {
+	var rb RateBreakdown
+	birthRate(defaultRateConfig, p.techLevel, standardOfLiving, pctCapacity, p.IsOnShip(), p.IsResortColony(), &rb.Birth)
+	deathRate(defaultRateConfig, p.techLevel, standardOfLiving, pctCapacity, 0, &rb.Death)
+	return rb
 }
 
 // Rebels implements the PopulationGroup interface.
 func (p Civilian) Rebels() int {
-	return p.qty.rebel
+	return p.qty.rebel + p.factions.total()
+}
+
+// Repair returns a copy of the population with every field forced into
+// its valid range, along with a description of each change. It is the
+// lenient counterpart to Validate: negative counts are set to zero,
+// factions with negative counts are removed, the tech level is clamped
+// to 0 to 10, an unknown colony kind or environment is reset to the
+// default, residuals outside 0 to 1, invalid research points, and
+// radicalization outside 0 to 1 are cleared, and the garrison is limited
+// to the loyal citizens. A valid unit is returned unchanged with no
+// changes.
+func (p Civilian) Repair() (Civilian, []string) {
+	var changes []string
+	if p.qty.loyal < 0 {
+		changes = append(changes, fmt.Sprintf("loyal-citizens: %d: set to 0", p.qty.loyal))
+		p.qty.loyal = 0
+	}
+	if p.qty.rebel < 0 {
+		changes = append(changes, fmt.Sprintf("rebel-citizens: %d: set to 0", p.qty.rebel))
+		p.qty.rebel = 0
+	}
+	for _, faction := range p.factions.list() {
+		if faction.Rebels < 0 {
+			changes = append(changes, fmt.Sprintf("factions: %s: %d: removed", faction.Name, faction.Rebels))
+			p.factions, _ = p.factions.add(faction.Name, -faction.Rebels)
+		}
+	}
+	for turn, births := range p.births {
+		if births < 0 {
+			changes = append(changes, fmt.Sprintf("recent-births: %d: set to 0", births))
+			p.births[turn] = 0
+		}
+	}
+	if !(p.research >= 0) || math.IsInf(p.research, 1) {
+		changes = append(changes, fmt.Sprintf("research-points: %g: set to 0", p.research))
+		p.research = 0
+	}
+	if !(0 <= p.radical && p.radical <= 1) {
+		changes = append(changes, fmt.Sprintf("radicalization: %g: set to 0", p.radical))
+		p.radical = 0
+	}
+	if p.garrison < 0 {
+		changes = append(changes, fmt.Sprintf("garrison: %d: set to 0", p.garrison))
+		p.garrison = 0
+	} else if p.garrison > p.qty.loyal {
+		changes = append(changes, fmt.Sprintf("garrison: %d: set to %d", p.garrison, p.qty.loyal))
+		p.garrison = p.qty.loyal
+	}
+	if techLevel := clampTechLevel(p.techLevel); techLevel != p.techLevel {
+		changes = append(changes, fmt.Sprintf("tech-level: %d: set to %d", p.techLevel, techLevel))
+		p.techLevel = techLevel
+	}
+	if _, err := p.kind.MarshalText(); err != nil {
+		changes = append(changes, fmt.Sprintf("colony-kind: %d: set to %s", int(p.kind), OpenColony))
+		p.kind = OpenColony
+	}
+	if _, err := p.env.MarshalText(); err != nil {
+		changes = append(changes, fmt.Sprintf("environment: %d: set to %s", int(p.env), Benign))
+		p.env = Benign
+	}
+	if !(0 <= p.residual.births && p.residual.births < 1) {
+		changes = append(changes, fmt.Sprintf("birth-residual: %g: set to 0", p.residual.births))
+		p.residual.births = 0
+	}
+	if !(0 <= p.residual.deaths && p.residual.deaths < 1) {
+		changes = append(changes, fmt.Sprintf("death-residual: %g: set to 0", p.residual.deaths))
+		p.residual.deaths = 0
+	}
+	return p, changes
+}
+
+// ResearchPoints returns the research points toward the next tech level.
+func (p Civilian) ResearchPoints() float64 {
+	return p.research
+}
+
+// ResearchThreshold returns the research points needed to advance from
+// the current tech level. It is 100 points for each level after the
+// current one, so tech 0 needs 100 points, tech 5 needs 600, and tech 9
+// needs 1,000. It is zero at tech 10, since there is no next level.
+func (p Civilian) ResearchThreshold() float64 {
+	techLevel := clampTechLevel(p.techLevel)
+	if techLevel >= 10 {
+		return 0
+	}
+	return 100 * float64(techLevel+1)
+}
+
+// Snapshot returns a plain copy of the state of the population.
+func (p Civilian) Snapshot() PopulationSnapshot {
+	return PopulationSnapshot{
+		Loyal:      p.qty.loyal,
+		Rebel:      p.qty.rebel,
+		Tech:       p.techLevel,
+		Population: p.Population(),
+		Rebels:     p.Rebels(),
+	}
+}
+
+// Split returns the population after qty people leave, along with a new
+// unit holding the people who left. The garrison stays behind; the other
+// loyal citizens and the rebels leave in proportion, as with
+// DistributeDeaths, and each named faction loses its share. The new unit
+// keeps the location, tech level, and founding turn; the residuals and the
+// birth history stay behind. It returns an error if qty is negative or
+// more than the people outside the garrison.
+func (p Civilian) Split(qty int) (Civilian, Civilian, error) {
+	if free := p.Population() - p.garrison; qty < 0 || qty > free {
+		return p, Civilian{}, fmt.Errorf("split civilian: %d: must be 0 to %d", qty, free)
+	}
+	loyal, rebels := DistributeDeaths(p.qty.loyal-p.garrison, p.Rebels(), qty)
+	rest := p.killRebels(rebels)
+	rest.qty.loyal -= loyal
+
+	part := p
+	part.residual.births, part.residual.deaths = 0, 0
+	part.births = [birthHistoryTurns]int{}
+	part.qty.loyal, part.qty.rebel = loyal, p.qty.rebel-rest.qty.rebel
+	part.garrison = 0
+	part.factions = factions{}
+	for _, faction := range p.factions.list() {
+		part.factions, _ = part.factions.add(faction.Name, faction.Rebels-rest.factions.get(faction.Name))
+	}
+	return rest, part, nil
+}
+
+// SplitRebels returns the population after qty rebels are exiled, along
+// with a new unit holding the exiles. Loyal citizens are never taken. The
+// rebels are taken from the factions as with deaths, and the exiles keep
+// their factions, radicalization, location, tech level, and founding
+// turn; the residuals and the birth history stay behind. It returns an
+// error if qty is negative or more than the rebels.
+func (p Civilian) SplitRebels(qty int) (kept Civilian, exiled Civilian, err error) {
+	if qty < 0 || qty > p.Rebels() {
+		return p, Civilian{}, fmt.Errorf("split rebels: %d: must be 0 to %d", qty, p.Rebels())
+	}
+	kept = p.killRebels(qty)
+
+	exiled = p
+	exiled.residual.births, exiled.residual.deaths = 0, 0
+	exiled.births = [birthHistoryTurns]int{}
+	exiled.qty.loyal, exiled.qty.rebel = 0, p.qty.rebel-kept.qty.rebel
+	exiled.garrison = 0
+	exiled.factions = factions{}
+	for _, faction := range p.factions.list() {
+		exiled.factions, _ = exiled.factions.add(faction.Name, faction.Rebels-kept.factions.get(faction.Name))
+	}
+	return kept, exiled, nil
+}
+
+// Suppress returns the population after a crackdown on the rebels, along
+// with the number of rebels that return to loyalty. Strength, clamped to
+// 0 to 1, is the fraction of the rebels a crackdown would pacify if they
+// weren't radicalized; radicalization reduces it in proportion, so fully
+// radicalized rebels can't be pacified at all. The rebels are taken from
+// the factions as with deaths. A frozen unit is unchanged.
+func (p Civilian) Suppress(strength float64) (Civilian, int) {
+	if p.frozen {
+		return p, 0
+	}
+	pacified := int(float64(p.Rebels()) * clamp(strength, 0, 1) * (1 - p.radical))
+	if pacified <= 0 {
+		return p, 0
+	}
+	p = p.killRebels(pacified)
+	p.qty.loyal += pacified
+	return p, pacified
+}
+
+// TaxRevenue returns the revenue collected in one turn at the given tax rate.
+// Only loyal citizens pay taxes. Each 100 loyal citizens pay 1.0 times the
+// tax rate at tech 5, scaled by the tech yield factor.
+func (p Civilian) TaxRevenue(taxRate float64) float64 {
+	return float64(p.qty.loyal) * 0.01 * clamp(taxRate, 0, 1) * techYieldFactor(p.techLevel)
 }
 
 // TechLevel implements the TechLevel interface.
@@ -169,24 +1377,621 @@ func (p Civilian) TechLevel() int {
 	return p.techLevel
 }
 
-// UnmarshalJSON implements the json.Unmarshaler interface
-func (p *Civilian) UnmarshalJSON(data []byte) error {
-	dec := json.NewDecoder(bytes.NewReader(data))
-
+// toAux returns the json helper for the population.
+func (p Civilian) toAux() auxCivilian {
 	var aux auxCivilian
-	if err := dec.Decode(&aux); err != nil {
+	aux.LoyalCitizens = p.qty.loyal
+	aux.RebelCitizens = p.qty.rebel
+	for _, faction := range p.factions.list() {
+		if aux.Factions == nil {
+			aux.Factions = map[string]int{}
+		}
+		aux.Factions[faction.Name] = faction.Rebels
+	}
+	aux.TechLevel = p.techLevel
+	aux.ColonyKind = p.kind
+	aux.Environment = p.env
+	aux.OnShip = p.onShip
+	aux.Frozen = p.frozen
+	aux.FoundedTurn = p.founded
+	aux.BirthResidual = p.residual.births
+	aux.RecentBirths = p.recentBirths()
+	aux.Research = p.research
+	aux.Radical = p.radical
+	aux.Garrison = p.garrison
+	aux.DeathResidual = p.residual.deaths
+	return aux
+}
+
+// ToMap returns the population as a map that uses the same field names as
+// the json format. As with json, the optional fields are only present when
+// they are set. CivilianFromMap converts the map back to a Civilian.
+func (p Civilian) ToMap() map[string]any {
+	aux := p.toAux()
+	m := map[string]any{
+		"loyal-citizens": aux.LoyalCitizens,
+		"rebel-citizens": aux.RebelCitizens,
+		"tech-level":     aux.TechLevel,
+	}
+	if len(aux.Factions) != 0 {
+		named := map[string]any{}
+		for name, n := range aux.Factions {
+			named[name] = n
+		}
+		m["factions"] = named
+	}
+	if aux.ColonyKind != OpenColony {
+		m["colony-kind"] = aux.ColonyKind.String()
+	}
+	if aux.Environment != Benign {
+		m["environment"] = aux.Environment.String()
+	}
+	if aux.OnShip {
+		m["on-ship"] = true
+	}
+	if aux.Frozen {
+		m["frozen"] = true
+	}
+	if aux.FoundedTurn != 0 {
+		m["founded-turn"] = aux.FoundedTurn
+	}
+	if aux.BirthResidual != 0 {
+		m["birth-residual"] = aux.BirthResidual
+	}
+	if aux.DeathResidual != 0 {
+		m["death-residual"] = aux.DeathResidual
+	}
+	if aux.Research != 0 {
+		m["research-points"] = aux.Research
+	}
+	if aux.Radical != 0 {
+		m["radicalization"] = aux.Radical
+	}
+	if aux.Garrison != 0 {
+		m["garrison"] = aux.Garrison
+	}
+	if len(aux.RecentBirths) != 0 {
+		m["recent-births"] = aux.RecentBirths
+	}
+	return m
+}
+
+// TurnsToCapacity returns the number of turns until the population reaches
+// the capacity, assuming the standard of living does not change.
+// Percent capacity is recalculated each turn, so growth slows as the colony fills.
+// It returns -1 if deaths catch up with births before it reaches capacity
+// or if the unit is frozen.
+func (p Civilian) TurnsToCapacity(standardOfLiving float64, capacity int) int {
+	for turns := 0; ; turns++ {
+		pop := p.Population()
+		if pop >= capacity {
+			return turns
+		}
+		pctCapacity := PctCapacity(pop, capacity)
+		if p.frozen || p.IsExtinct() || p.NaturalBirthRate(standardOfLiving, pctCapacity) <= p.NaturalDeathRate(standardOfLiving, pctCapacity) {
+			return -1
+		}
+		p = p.ApplyTurn(standardOfLiving, pctCapacity)
+	}
+}
+
+// TurnsToExtinction returns the number of turns until the population dies
+// out, assuming the standard of living, percent capacity, and the FOOD
+// available each turn do not change. Each turn applies ApplyTurn and then
+// ApplyFoodShortage. It returns -1 if the population stabilizes, which is
+// when the food covers its needs and births keep up with deaths, or if the
+// unit is frozen. An extinct unit returns 0.
+func (p Civilian) TurnsToExtinction(standardOfLiving, pctCapacity float64, foodAvailable float64) int {
+	for turns := 0; ; turns++ {
+		if p.IsExtinct() {
+			return turns
+		}
+		fed := p.FoodNeeded() <= foodAvailable
+		if p.frozen || (fed && p.NaturalBirthRate(standardOfLiving, pctCapacity) >= p.NaturalDeathRate(standardOfLiving, pctCapacity)) {
+			return -1
+		}
+		p, _ = p.ApplyTurn(standardOfLiving, pctCapacity).ApplyFoodShortage(foodAvailable)
+	}
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+// It decodes the packed format written by MarshalBinary and returns an
+// error if the data is truncated, has trailing bytes, or is out of range.
+func (p *Civilian) UnmarshalBinary(data []byte) error {
+	loyal, n := binary.Uvarint(data)
+	if n <= 0 {
+		return fmt.Errorf("decode civilian: loyal-citizens: invalid varint")
+	}
+	data = data[n:]
+	rebel, n := binary.Uvarint(data)
+	if n <= 0 {
+		return fmt.Errorf("decode civilian: rebel-citizens: invalid varint")
+	}
+	data = data[n:]
+	if len(data) < 2 {
+		return fmt.Errorf("decode civilian: unexpected end of data")
+	}
+	if loyal > math.MaxInt || rebel > math.MaxInt {
+		return fmt.Errorf("decode civilian: population out of range")
+	}
+	tech, flags := data[0], data[1]
+	data = data[2:]
+	var q Civilian
+	q.qty.loyal, q.qty.rebel = int(loyal), int(rebel)
+	q.techLevel = int(tech)
+	q.kind = ColonyKind(flags & 0x03)
+	q.env = Environment(flags >> 2 & 0x03)
+	q.onShip = flags&binaryOnShip != 0
+	if flags&binaryResidual != 0 {
+		if len(data) < 16 {
+			return fmt.Errorf("decode civilian: unexpected end of data")
+		}
+		q.residual.births = math.Float64frombits(binary.LittleEndian.Uint64(data))
+		q.residual.deaths = math.Float64frombits(binary.LittleEndian.Uint64(data[8:]))
+		data = data[16:]
+	}
+	if flags&binaryFactions != 0 {
+		if len(data) < 1 {
+			return fmt.Errorf("decode civilian: unexpected end of data")
+		}
+		count := int(data[0])
+		data = data[1:]
+		for i := 0; i < count; i++ {
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return fmt.Errorf("decode civilian: factions: invalid name")
+			}
+			name := string(data[n : n+int(length)])
+			data = data[n+int(length):]
+			rebels, n := binary.Uvarint(data)
+			if n <= 0 || rebels > math.MaxInt {
+				return fmt.Errorf("decode civilian: factions: %q: invalid varint", name)
+			}
+			data = data[n:]
+			var ok bool
+			if q.factions, ok = q.factions.add(name, int(rebels)); !ok {
+				return fmt.Errorf("decode civilian: factions: more than %d", maxFactions)
+			}
+		}
+	}
+	var ext byte
+	if flags&binaryExtended != 0 {
+		if len(data) < 1 {
+			return fmt.Errorf("decode civilian: unexpected end of data")
+		}
+		ext, data = data[0], data[1:]
+		if ext == 0 || ext&^binaryExtensions != 0 {
+			return fmt.Errorf("decode civilian: extension: %#02x: invalid flags", ext)
+		}
+	}
+	if ext&binaryFounded != 0 {
+		founded, n := binary.Varint(data)
+		if n <= 0 || founded < math.MinInt || founded > math.MaxInt {
+			return fmt.Errorf("decode civilian: founded-turn: invalid varint")
+		}
+		q.founded = int(founded)
+		data = data[n:]
+	}
+	if ext&binaryHistory != 0 {
+		if len(data) < 1 {
+			return fmt.Errorf("decode civilian: unexpected end of data")
+		}
+		count := int(data[0])
+		if count < 1 || count > birthHistoryTurns {
+			return fmt.Errorf("decode civilian: recent-births: %d: must be 1 to %d turns", count, birthHistoryTurns)
+		}
+		data = data[1:]
+		for turn := 0; turn < count; turn++ {
+			births, n := binary.Uvarint(data)
+			if n <= 0 || births > math.MaxInt {
+				return fmt.Errorf("decode civilian: recent-births: invalid varint")
+			}
+			q.births[turn] = int(births)
+			data = data[n:]
+		}
+	}
+	if ext&binaryResearch != 0 {
+		if len(data) < 8 {
+			return fmt.Errorf("decode civilian: research-points: unexpected end of data")
+		}
+		q.research = math.Float64frombits(binary.LittleEndian.Uint64(data))
+		data = data[8:]
+	}
+	if ext&binaryRadical != 0 {
+		if len(data) < 8 {
+			return fmt.Errorf("decode civilian: radicalization: unexpected end of data")
+		}
+		q.radical = math.Float64frombits(binary.LittleEndian.Uint64(data))
+		data = data[8:]
+	}
+	if ext&binaryGarrison != 0 {
+		garrison, n := binary.Uvarint(data)
+		if n <= 0 || garrison > math.MaxInt {
+			return fmt.Errorf("decode civilian: garrison: invalid varint")
+		}
+		q.garrison = int(garrison)
+		data = data[n:]
+	}
+	q.frozen = ext&binaryFrozen != 0
+	if len(data) != 0 {
+		return fmt.Errorf("decode civilian: %d trailing bytes", len(data))
+	}
+	if err := q.Validate(); err != nil {
 		return fmt.Errorf("decode civilian: %w", err)
 	}
+	*p = q
+	return nil
+}
 
-	p.qty.loyal = aux.LoyalCitizens
-	p.qty.rebel = aux.RebelCitizens
-	p.techLevel = aux.TechLevel
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// It accepts the old "loyal" and "rebel" field names from earlier save files.
+// When both spellings are present, the new names are used.
+func (p *Civilian) UnmarshalJSON(data []byte) error {
+	aux, err := decodeAuxCivilian(data)
+	if err != nil {
+		return fmt.Errorf("decode civilian: %w", err)
+	}
+	q, err := fromAux(aux)
+	if err == nil {
+		err = q.Validate()
+	}
+	if err != nil {
+		return fmt.Errorf("decode civilian: %w", err)
+	}
+	*p = q
 
 	return nil
 }
 
+// UnmarshalProto decodes a protobuf message in the layout written by
+// MarshalProto. Fields may be in any order, the last value of a field
+// wins, and unknown fields are skipped, as protobuf requires. The rebels
+// are loaded into the default faction.
+func (p *Civilian) UnmarshalProto(data []byte) error {
+	var q Civilian
+	for len(data) != 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("decode civilian: invalid field key")
+		}
+		data = data[n:]
+		number, wireType := key>>3, key&7
+		switch wireType {
+		case protoVarint:
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("decode civilian: field %d: invalid varint", number)
+			} else if v > math.MaxInt64 && number <= 3 {
+				return fmt.Errorf("decode civilian: field %d: %d: out of range", number, v)
+			}
+			data = data[n:]
+			switch number {
+			case 1:
+				q.qty.loyal = int(v)
+			case 2:
+				q.qty.rebel = int(v)
+			case 3:
+				q.techLevel = int(v)
+			}
+		case protoFixed64:
+			if len(data) < 8 {
+				return fmt.Errorf("decode civilian: field %d: unexpected end of data", number)
+			}
+			data = data[8:]
+		case protoBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return fmt.Errorf("decode civilian: field %d: invalid length", number)
+			}
+			data = data[n+int(length):]
+		case protoFixed32:
+			if len(data) < 4 {
+				return fmt.Errorf("decode civilian: field %d: unexpected end of data", number)
+			}
+			data = data[4:]
+		default:
+			return fmt.Errorf("decode civilian: field %d: unknown wire type %d", number, wireType)
+		}
+	}
+	if err := q.Validate(); err != nil {
+		return fmt.Errorf("decode civilian: %w", err)
+	}
+	*p = q
+	return nil
+}
+
+// Validate returns an error naming the first field that is out of range.
+// Counts must not be negative, and tech level must be 0 to 10.
+func (p Civilian) Validate() error {
+	if p.qty.loyal < 0 {
+		return fmt.Errorf("loyal-citizens: %d: must not be negative", p.qty.loyal)
+	} else if p.qty.rebel < 0 {
+		return fmt.Errorf("rebel-citizens: %d: must not be negative", p.qty.rebel)
+	}
+	for _, faction := range p.factions.list() {
+		if faction.Rebels < 0 {
+			return fmt.Errorf("factions: %s: %d: must not be negative", faction.Name, faction.Rebels)
+		}
+	}
+	for _, births := range p.births {
+		if births < 0 {
+			return fmt.Errorf("recent-births: %d: must not be negative", births)
+		}
+	}
+	if !(p.research >= 0) || math.IsInf(p.research, 1) {
+		return fmt.Errorf("research-points: %g: must be a non-negative number", p.research)
+	} else if !(0 <= p.radical && p.radical <= 1) {
+		return fmt.Errorf("radicalization: %g: must be 0 to 1", p.radical)
+	} else if !(0 <= p.garrison && p.garrison <= p.qty.loyal) {
+		return fmt.Errorf("garrison: %d: must be 0 to %d", p.garrison, p.qty.loyal)
+	}
+	if p.Rebels() > p.Population() {
+		return fmt.Errorf("rebel-citizens: %d: must not exceed population %d", p.Rebels(), p.Population())
+	} else if !(0 <= p.techLevel && p.techLevel <= 10) {
+		return fmt.Errorf("tech-level: %d: must be 0 to 10", p.techLevel)
+	} else if _, err := p.kind.MarshalText(); err != nil {
+		return fmt.Errorf("colony-kind: %w", err)
+	} else if _, err := p.env.MarshalText(); err != nil {
+		return fmt.Errorf("environment: %w", err)
+	}
+	return nil
+}
+
 // Volume implements the Unit interface.
+// The volume per unit is 1.00 at tech 5 and is scaled by tech level.
 func (p Civilian) Volume() float64 {
 	const volumePerUnit = 1.00 // per 100
-	return p.Quantity() * volumePerUnit
+	return p.Quantity() * volumePerUnit * techVolumeFactor(p.techLevel)
+}
+
+// WillChange returns false if ApplyTurn would return an Equal population,
+// so that a turn engine can skip the unit. Extinct and frozen units never
+// change. It counts the births and deaths with the same rounding as
+// ApplyTurn, including the residuals, but doesn't build the new unit.
+// A unit changes when a residual moves by more than floating point noise,
+// when any rebel dies, when the births don't replace the loyal deaths, or
+// when the deaths would shrink the garrison.
+func (p Civilian) WillChange(standardOfLiving, pctCapacity float64) bool {
+	if p.IsExtinct() || p.frozen {
+		return false
+	}
+	births, deaths, birthResidual, deathResidual := p.turnCounts(p.NaturalBirthRate(standardOfLiving, pctCapacity), p.NaturalDeathRate(standardOfLiving, pctCapacity))
+	if !isClose(birthResidual, p.residual.births) || !isClose(deathResidual, p.residual.deaths) {
+		return true
+	}
+	loyalDeaths, rebelDeaths := distributeDeaths(p.qty.loyal, p.Rebels(), deaths, 0)
+	return rebelDeaths != 0 || loyalDeaths != births || p.garrison > p.qty.loyal-loyalDeaths
+}
+
+// WithColonyKind returns a copy of the population living in the given kind of colony.
+func (p Civilian) WithColonyKind(kind ColonyKind) Civilian {
+	p.kind = kind
+	return p
+}
+
+// WithEnvironment returns a copy of the unit on a planet with the given environment.
+func (p Civilian) WithEnvironment(env Environment) Civilian {
+	p.env = env
+	return p
+}
+
+// WithFaction returns a copy of the population with the number of rebels
+// in the named faction set. The empty name sets the default faction.
+// A unit tracks at most four named factions.
+func (p Civilian) WithFaction(name string, rebels int) (Civilian, error) {
+	if rebels < 0 {
+		return p, fmt.Errorf("factions: %s: %d: must not be negative", name, rebels)
+	} else if name == "" {
+		p.qty.rebel = rebels
+		return p, nil
+	}
+	f, ok := p.factions.add(name, rebels-p.factions.get(name))
+	if !ok {
+		return p, fmt.Errorf("factions: %s: more than %d", name, maxFactions)
+	}
+	p.factions = f
+	return p, nil
+}
+
+// WithFoundedTurn returns a copy of the population founded on the given turn.
+func (p Civilian) WithFoundedTurn(turn int) Civilian {
+	p.founded = turn
+	return p
+}
+
+// WithFrozen returns a copy of the unit that is (or is not) frozen.
+// A frozen unit, for example one in stasis, neither grows nor dies:
+// ApplyTurn and its variants, ApplyCrisis, ApplyEpidemic,
+// ApplyFoodShortage, ApplyResearch, ApplyUnrest, and Suppress return it
+// unchanged.
+func (p Civilian) WithFrozen(frozen bool) Civilian {
+	p.frozen = frozen
+	return p
+}
+
+// WithGarrison returns a copy of the unit with garrison of its loyal
+// citizens pinned in place. The garrison doesn't migrate and never turns
+// rebel, but it is still part of the population: it eats, needs life
+// support, and dies of natural causes (last, after the other loyal
+// citizens). It returns an error if garrison is negative or more than the
+// loyal citizens.
+func (p Civilian) WithGarrison(garrison int) (Civilian, error) {
+	if garrison < 0 || garrison > p.qty.loyal {
+		return p, fmt.Errorf("garrison: %d: must be 0 to %d", garrison, p.qty.loyal)
+	}
+	p.garrison = garrison
+	return p, nil
+}
+
+// WithShip returns a copy of the population that is (or is not) on a ship.
+func (p Civilian) WithShip(onShip bool) Civilian {
+	p.onShip = onShip
+	return p
+}
+
+// applyRates applies births and deaths for one turn, with deaths skewed
+// toward the rebels by the bias.
+// Both are calculated from the population at the start of the turn,
+// including the residual fractions from earlier turns. Births are limited
+// so the population doesn't pass maxPopulation.
+func (p Civilian) applyRates(birthRate, deathRate, rebelDeathBias float64, maxPopulation int) Civilian {
+	if p.frozen {
+		return p
+	}
+	var births, deaths int
+	births, deaths, p.residual.births, p.residual.deaths = p.turnCounts(birthRate, deathRate)
+	p = p.kill(deaths, rebelDeathBias)
+	if room := maxPopulation - p.Population(); births > room {
+		births, p.residual.births = 0, 0
+		if room > 0 {
+			births = room
+		}
+	}
+	p.qty.loyal += births
+	copy(p.births[1:], p.births[:birthHistoryTurns-1])
+	p.births[0] = births
+	return p
+}
+
+// turnCounts returns the whole births and deaths for one turn at the given
+// rates, along with the fractions left over for the next turn. Both are
+// calculated from the population at the start of the turn.
+func (p Civilian) turnCounts(birthRate, deathRate float64) (births, deaths int, birthResidual, deathResidual float64) {
+	pop := float64(p.Population())
+	exactBirths, exactDeaths := pop*birthRate+p.residual.births, pop*deathRate+p.residual.deaths
+	births, deaths = int(exactBirths), int(exactDeaths)
+	return births, deaths, exactBirths - float64(births), exactDeaths - float64(deaths)
+}
+
+// killRebels removes deaths from the rebels. Each named faction loses its
+// share of the deaths, rounded down, and the default faction loses the rest.
+// If the default faction runs out, the named factions lose the remainder.
+func (p Civilian) killRebels(deaths int) Civilian {
+	total := p.Rebels()
+	if deaths <= 0 || total == 0 {
+		return p
+	} else if deaths >= total {
+		p.qty.rebel, p.factions = 0, factions{}
+		return p
+	}
+	remaining := deaths
+	for i := range p.factions {
+		share := deaths * p.factions[i].Rebels / total
+		p.factions[i].Rebels, remaining = p.factions[i].Rebels-share, remaining-share
+	}
+	if remaining <= p.qty.rebel {
+		p.qty.rebel, remaining = p.qty.rebel-remaining, 0
+	} else {
+		p.qty.rebel, remaining = 0, remaining-p.qty.rebel
+	}
+	for i := range p.factions {
+		if remaining <= p.factions[i].Rebels {
+			p.factions[i].Rebels, remaining = p.factions[i].Rebels-remaining, 0
+		} else {
+			p.factions[i].Rebels, remaining = 0, remaining-p.factions[i].Rebels
+		}
+	}
+	p.factions = p.factions.compact()
+	return p
+}
+
+// mergeFactions returns the population with the named factions added.
+// Factions that don't fit join the default faction.
+func (p Civilian) mergeFactions(f factions) Civilian {
+	for _, faction := range f.list() {
+		if merged, ok := p.factions.add(faction.Name, faction.Rebels); ok {
+			p.factions = merged
+		} else {
+			p.qty.rebel += faction.Rebels
+		}
+	}
+	return p
+}
+
+// recentBirths returns the birth history, newest first, without the
+// turns at the end that had no births.
+func (p Civilian) recentBirths() []int {
+	n := birthHistoryTurns
+	for n > 0 && p.births[n-1] == 0 {
+		n--
+	}
+	if n == 0 {
+		return nil
+	}
+	return append([]int(nil), p.births[:n]...)
+}
+
+// fromAux returns the population from the json helper.
+// Callers must check the result with Validate or Repair it.
+func fromAux(aux auxCivilian) (Civilian, error) {
+	var p Civilian
+	p.qty.loyal = aux.LoyalCitizens
+	p.qty.rebel = aux.RebelCitizens
+	for name, rebels := range aux.Factions {
+		if name == "" {
+			return Civilian{}, fmt.Errorf("factions: name must not be empty")
+		}
+		var ok bool
+		if p.factions, ok = p.factions.add(name, rebels); !ok {
+			return Civilian{}, fmt.Errorf("factions: more than %d", maxFactions)
+		}
+	}
+	p.techLevel = aux.TechLevel
+	p.kind = aux.ColonyKind
+	p.env = aux.Environment
+	p.onShip = aux.OnShip
+	p.frozen = aux.Frozen
+	p.founded = aux.FoundedTurn
+	p.residual.births = aux.BirthResidual
+	p.residual.deaths = aux.DeathResidual
+	if len(aux.RecentBirths) > birthHistoryTurns {
+		return Civilian{}, fmt.Errorf("recent-births: more than %d turns", birthHistoryTurns)
+	}
+	copy(p.births[:], aux.RecentBirths)
+	p.research = aux.Research
+	p.radical = aux.Radical
+	p.garrison = aux.Garrison
+	return p, nil
+}
+
+// decodeAuxCivilian decodes the json helper, loading data written with
+// older field names. The values are not checked.
+func decodeAuxCivilian(data []byte) (auxCivilian, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	var aux auxCivilian
+	if err := dec.Decode(&aux); err != nil {
+		return auxCivilian{}, err
+	}
+	var aliases auxCivilianAliases
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return auxCivilian{}, err
+	}
+	if aliases.LoyalCitizens == nil && aliases.Loyal != nil {
+		aux.LoyalCitizens = *aliases.Loyal
+	}
+	if aliases.RebelCitizens == nil && aliases.Rebel != nil {
+		aux.RebelCitizens = *aliases.Rebel
+	}
+	return aux, nil
+}
+
+// distributeDeaths implements DistributeDeaths, moving the rebel deaths
+// from their proportional share toward the most rebels that can die by
+// the bias, which is clamped to 0 to 1.
+func distributeDeaths(loyal, rebel, deaths int, rebelDeathBias float64) (loyalDeaths, rebelDeaths int) {
+	pop := loyal + rebel
+	if deaths <= 0 || pop <= 0 {
+		return 0, 0
+	} else if deaths > pop {
+		deaths = pop
+	}
+	rebelDeaths = deaths * rebel / pop
+	if mostRebels := deaths; rebelDeathBias > 0 {
+		if mostRebels > rebel {
+			mostRebels = rebel
+		}
+		rebelDeaths += int(clamp(rebelDeathBias, 0, 1) * float64(mostRebels-rebelDeaths))
+	}
+	return deaths - rebelDeaths, rebelDeaths
 }
@@ -17,6 +17,10 @@
 package wge_test
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
 	"testing"
 
 	"github.com/maloquacious/wge"
@@ -57,7 +61,10 @@ func TestCivilians(t *testing.T) {
 		{2, 2, 0.5, 0.8, 0.03125},
 		{3, 3, 0.75, 0.5, 0.10},
 		{4, 4, 1.25, 0.3, 0.0825},
-		{5, 10, 2, 0.9, 0.0075},
+		{5, 10, 2, 0.9, 0.005},
+		{6, 10, 1.3, 0.5, 0.075},
+		{7, 10, 2.0, 0.5, 0.050},
+		{8, 10, 1.75, 0.5, 0.075},
 	} {
 		p := wge.NewCivilian(1000, tc.techLevel)
 		birthRate := p.NaturalBirthRate(tc.standardOfLiving, tc.pctCapacity)
@@ -80,6 +87,11 @@ func TestCivilians(t *testing.T) {
 		{4, 10, 0.25, 0.50, 0.00_5875},
 		{5, 10, 2, 0.50, 0.00_4875},
 		{6, 10, 1, 0.99, 0.00_6250},
+		{7, 10, 1, 1.50, 0.00_7500},
+		{8, 10, 1, 2.10, 0.01_5000},
+		{9, 10, 1, 2.50, 0.02_5000},
+		{10, 10, 1, 5.00, 0.25_0000},
+		{11, 0, 1, 5.00, 0.75_0000},
 	} {
 		p := wge.NewCivilian(1000, tc.techLevel)
 		deathRate := p.NaturalDeathRate(tc.standardOfLiving, tc.pctCapacity)
@@ -88,3 +100,1724 @@ func TestCivilians(t *testing.T) {
 		}
 	}
 }
+
+func TestCivilianUnmarshalAliases(t *testing.T) {
+	// verify that old and new field names decode to equal civilians
+	for _, tc := range []struct {
+		id     int
+		data   string
+		pop    int
+		rebels int
+	}{
+		{1, `{"loyal-citizens":900,"rebel-citizens":100,"tech-level":3}`, 1000, 100},
+		{2, `{"loyal":900,"rebel":100,"tech-level":3}`, 1000, 100},
+		{3, `{"loyal-citizens":900,"loyal":1,"rebel-citizens":100,"rebel":2,"tech-level":3}`, 1000, 100},
+		{4, `{"loyal-citizens":900,"rebel":100,"tech-level":3}`, 1000, 100},
+	} {
+		var p wge.Civilian
+		if err := json.Unmarshal([]byte(tc.data), &p); err != nil {
+			t.Errorf("aliases: %d: expected nil, got %v\n", tc.id, err)
+			continue
+		}
+		if tc.pop != p.Population() || tc.rebels != p.Rebels() || p.TechLevel() != 3 {
+			t.Errorf("aliases: %d: expected %d/%d/3, got %d/%d/%d\n", tc.id, tc.pop, tc.rebels, p.Population(), p.Rebels(), p.TechLevel())
+		}
+	}
+
+	var oldNames, newNames wge.Civilian
+	if err := json.Unmarshal([]byte(`{"loyal":50,"rebel":7,"tech-level":9}`), &oldNames); err != nil {
+		t.Fatalf("aliases: old: expected nil, got %v\n", err)
+	}
+	if err := json.Unmarshal([]byte(`{"loyal-citizens":50,"rebel-citizens":7,"tech-level":9}`), &newNames); err != nil {
+		t.Fatalf("aliases: new: expected nil, got %v\n", err)
+	}
+	if oldNames != newNames {
+		t.Errorf("aliases: expected old and new names to be equal, got %+v and %+v\n", oldNames, newNames)
+	}
+}
+
+func TestCivilianFoodNeeded(t *testing.T) {
+	// verify that food demand falls as tech level rises
+	for _, tc := range []struct {
+		id        int
+		techLevel int
+		expect    float64
+	}{
+		{1, 0, 1000 * 0.01 * 0.0125 * 1.25},
+		{2, 5, 1000 * 0.01 * 0.0125},
+		{3, 10, 1000 * 0.01 * 0.0125 * 0.75},
+	} {
+		p := wge.NewCivilian(1000, tc.techLevel)
+		if got := p.FoodNeeded(); !isClose(tc.expect, got) {
+			t.Errorf("foodNeeded: %d: expected %8.4f, got %8.4f\n", tc.id, tc.expect, got)
+		}
+	}
+	if lo, hi := wge.NewCivilian(1000, 0).FoodNeeded(), wge.NewCivilian(1000, 10).FoodNeeded(); !(hi < lo) {
+		t.Errorf("foodNeeded: expected tech 10 (%8.4f) < tech 0 (%8.4f)\n", hi, lo)
+	}
+}
+
+func TestCivilianLifeSupportNeeded(t *testing.T) {
+	// verify that life support demand falls as tech level rises
+	for _, tc := range []struct {
+		id        int
+		techLevel int
+		expect    float64
+	}{
+		{1, 0, 1000 * 0.01 * 0.5 * 1.40},
+		{2, 5, 1000 * 0.01 * 0.5},
+		{3, 10, 1000 * 0.01 * 0.5 * 0.60},
+	} {
+		ship := wge.NewCivilian(1000, tc.techLevel).WithShip(true)
+		if got := ship.LifeSupportNeeded(); !isClose(tc.expect, got) {
+			t.Errorf("lifeSupportNeeded: ship: %d: expected %8.4f, got %8.4f\n", tc.id, tc.expect, got)
+		}
+		closed := wge.NewCivilian(1000, tc.techLevel).WithColonyKind(wge.ClosedColony)
+		if got := closed.LifeSupportNeeded(); !isClose(tc.expect, got) {
+			t.Errorf("lifeSupportNeeded: closed: %d: expected %8.4f, got %8.4f\n", tc.id, tc.expect, got)
+		}
+		// open colonies don't consume life support at any tech level
+		open := wge.NewCivilian(1000, tc.techLevel)
+		if got := open.LifeSupportNeeded(); got != 0 {
+			t.Errorf("lifeSupportNeeded: open: %d: expected %8.4f, got %8.4f\n", tc.id, 0.0, got)
+		}
+	}
+
+	// verify that the colony kind and ship flag survive a round trip
+	p := wge.NewCivilian(1000, 5).WithColonyKind(wge.ResortColony).WithShip(true)
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("lifeSupportNeeded: marshal: expected nil, got %v\n", err)
+	}
+	var q wge.Civilian
+	if err := json.Unmarshal(data, &q); err != nil {
+		t.Fatalf("lifeSupportNeeded: unmarshal: expected nil, got %v\n", err)
+	}
+	if p != q {
+		t.Errorf("lifeSupportNeeded: round trip: expected %s to match\n", string(data))
+	}
+}
+
+func TestMergeAll(t *testing.T) {
+	// build units with rebels so that discontent depends on tech loss
+	var a, b, c wge.Civilian
+	for _, tc := range []struct {
+		p    *wge.Civilian
+		data string
+	}{
+		{&a, `{"loyal-citizens":5000,"rebel-citizens":1000,"tech-level":8}`},
+		{&b, `{"loyal-citizens":3000,"rebel-citizens":2000,"tech-level":6}`},
+		{&c, `{"loyal-citizens":9000,"rebel-citizens":500,"tech-level":2}`},
+	} {
+		if err := json.Unmarshal([]byte(tc.data), tc.p); err != nil {
+			t.Fatalf("mergeAll: expected nil, got %v\n", err)
+		}
+	}
+
+	// verify that the result is independent of argument order
+	expect := wge.MergeAll(a, b, c)
+	for i, units := range [][]wge.Civilian{
+		{a, c, b},
+		{b, a, c},
+		{b, c, a},
+		{c, a, b},
+		{c, b, a},
+	} {
+		if got := wge.MergeAll(units...); got != expect {
+			t.Errorf("mergeAll: %d: expected %+v, got %+v\n", i+1, expect, got)
+		}
+	}
+
+	// total population is conserved and tech is the weighted average
+	if got := expect.Population(); got != 20_500 {
+		t.Errorf("mergeAll: expected population %d, got %d\n", 20_500, got)
+	}
+	if got := expect.TechLevel(); got != (6000*8+5000*6+9500*2)/20_500 {
+		t.Errorf("mergeAll: expected tech-level %d, got %d\n", (6000*8+5000*6+9500*2)/20_500, got)
+	}
+	// tech 4: a loses 4 levels (40 rebels), b loses 2 levels (40 rebels)
+	if got := expect.Rebels(); got != 3500+80 {
+		t.Errorf("mergeAll: expected rebels %d, got %d\n", 3500+80, got)
+	}
+}
+
+func TestCivilianProject(t *testing.T) {
+	// verify that a growing colony grows every turn
+	colony := wge.NewCivilian(10_000, 5)
+	series := colony.Project(1.0, 0.5, 10)
+	if len(series) != 10 {
+		t.Fatalf("project: colony: expected 10 turns, got %d\n", len(series))
+	}
+	prior := colony.Population()
+	for turn, pop := range series {
+		if !(pop > prior) {
+			t.Errorf("project: colony: turn %d: expected > %d, got %d\n", turn+1, prior, pop)
+		}
+		prior = pop
+	}
+	// and that projecting does not change the colony
+	if colony.Population() != 10_000 {
+		t.Errorf("project: colony: expected population %d, got %d\n", 10_000, colony.Population())
+	}
+
+	// verify that a ship never grows, since there are no births
+	ship := wge.NewCivilian(10_000, 5).WithShip(true)
+	prior = ship.Population()
+	for turn, pop := range ship.Project(1.0, 0.5, 10) {
+		if pop > prior {
+			t.Errorf("project: ship: turn %d: expected <= %d, got %d\n", turn+1, prior, pop)
+		}
+		prior = pop
+	}
+	if prior == ship.Population() {
+		t.Errorf("project: ship: expected deaths, got none\n")
+	}
+}
+
+func TestCivilianTurnsToCapacity(t *testing.T) {
+	// a resort colony with a low standard of living grows quickly
+	fast := wge.NewCivilian(1_000, 6).WithColonyKind(wge.ResortColony)
+	turns := fast.TurnsToCapacity(0.1, 10_000)
+	if turns <= 0 {
+		t.Fatalf("turnsToCapacity: fast: expected > 0, got %d\n", turns)
+	}
+	if turns > 50 {
+		t.Errorf("turnsToCapacity: fast: expected <= 50, got %d\n", turns)
+	}
+	// a colony already at capacity needs no turns
+	if got := wge.NewCivilian(10_000, 1).TurnsToCapacity(1.0, 10_000); got != 0 {
+		t.Errorf("turnsToCapacity: full: expected 0, got %d\n", got)
+	}
+	// a ship has no births, so it shrinks and never reaches capacity
+	shrinking := wge.NewCivilian(1_000, 10).WithShip(true)
+	if got := shrinking.TurnsToCapacity(2.0, 10_000); got != -1 {
+		t.Errorf("turnsToCapacity: shrinking: expected -1, got %d\n", got)
+	}
+	// a high-tech colony stalls short of capacity
+	if got := wge.NewCivilian(1_000, 10).TurnsToCapacity(2.0, 10_000); got != -1 {
+		t.Errorf("turnsToCapacity: stalled: expected -1, got %d\n", got)
+	}
+}
+
+func TestCivilianApplyEpidemic(t *testing.T) {
+	for _, tc := range []struct {
+		id          int
+		techLevel   int
+		severity    float64
+		pctCapacity float64
+		expect      int
+	}{
+		{1, 0, 0.0, 0.5, 0},
+		{2, 0, 0.1, 0.5, 1_000},
+		{3, 10, 0.1, 0.5, 500},
+		{4, 0, 0.1, 1.0, 1_500},
+		{5, 0, 1.0, 1.0, 7_500},
+	} {
+		p := wge.NewCivilian(10_000, tc.techLevel)
+		q, deaths := p.ApplyEpidemic(tc.severity, tc.pctCapacity)
+		if tc.expect != deaths {
+			t.Errorf("epidemic: %d: expected %d deaths, got %d\n", tc.id, tc.expect, deaths)
+		}
+		if got := p.Population() - q.Population(); got != deaths {
+			t.Errorf("epidemic: %d: expected population to fall by %d, got %d\n", tc.id, deaths, got)
+		}
+	}
+
+	// the same severity kills fewer at higher tech and more when crowded
+	_, lowTech := wge.NewCivilian(10_000, 2).ApplyEpidemic(0.2, 0.5)
+	_, highTech := wge.NewCivilian(10_000, 8).ApplyEpidemic(0.2, 0.5)
+	if !(highTech < lowTech) {
+		t.Errorf("epidemic: expected tech 8 (%d) < tech 2 (%d)\n", highTech, lowTech)
+	}
+	_, roomy := wge.NewCivilian(10_000, 5).ApplyEpidemic(0.2, 0.3)
+	_, crowded := wge.NewCivilian(10_000, 5).ApplyEpidemic(0.2, 0.95)
+	if !(roomy < crowded) {
+		t.Errorf("epidemic: expected roomy (%d) < crowded (%d)\n", roomy, crowded)
+	}
+}
+
+func TestCivilianFingerprint(t *testing.T) {
+	var p, q, r wge.Civilian
+	for _, tc := range []struct {
+		p    *wge.Civilian
+		data string
+	}{
+		{&p, `{"loyal-citizens":5000,"rebel-citizens":100,"tech-level":4}`},
+		{&q, `{"loyal":5000,"rebel":100,"tech-level":4}`},
+		{&r, `{"loyal-citizens":5000,"rebel-citizens":101,"tech-level":4}`},
+	} {
+		if err := json.Unmarshal([]byte(tc.data), tc.p); err != nil {
+			t.Fatalf("fingerprint: expected nil, got %v\n", err)
+		}
+	}
+
+	// equal units share a fingerprint
+	if !p.Equal(q) {
+		t.Fatalf("fingerprint: expected %+v to equal %+v\n", p, q)
+	}
+	if p.Fingerprint() != q.Fingerprint() {
+		t.Errorf("fingerprint: expected equal units to match, got %x and %x\n", p.Fingerprint(), q.Fingerprint())
+	}
+	// the fingerprint is stable across runs
+	if got := wge.NewCivilian(0, 0).Fingerprint(); got != 0x81d23fd7003c2305 {
+		t.Errorf("fingerprint: zero: expected %x, got %x\n", uint64(0x81d23fd7003c2305), got)
+	}
+	// a one-rebel change flips the fingerprint
+	if p.Equal(r) {
+		t.Errorf("fingerprint: expected %+v to differ from %+v\n", p, r)
+	}
+	if p.Fingerprint() == r.Fingerprint() {
+		t.Errorf("fingerprint: expected one-rebel change to flip fingerprint, got %x\n", p.Fingerprint())
+	}
+	// swapping loyal and tech produces a different fingerprint
+	if wge.NewCivilian(4, 5000).Fingerprint() == wge.NewCivilian(5000, 4).Fingerprint() {
+		t.Errorf("fingerprint: expected field order to matter\n")
+	}
+}
+
+func TestCivilianApplyCrisis(t *testing.T) {
+	for _, tc := range []struct {
+		id             int
+		prior, current float64
+		expect         int
+	}{
+		{1, 1.0, 1.0, 0},
+		{2, 1.0, 1.5, 0},
+		{3, 1.0, 0.75, 312},
+		{4, 1.0, 0.2, 3_200},
+		{5, 2.0, 0.0, 5_000},
+	} {
+		p := wge.NewCivilian(10_000, 5).ApplyCrisis(tc.prior, tc.current)
+		if got := p.Rebels(); tc.expect != got {
+			t.Errorf("crisis: %d: expected %d rebels, got %d\n", tc.id, tc.expect, got)
+		}
+		if got := p.Population(); got != 10_000 {
+			t.Errorf("crisis: %d: expected population %d, got %d\n", tc.id, 10_000, got)
+		}
+	}
+
+	// a crash produces far more rebels than a gentle decline
+	gentle := wge.NewCivilian(10_000, 5).ApplyCrisis(1.0, 0.95).Rebels()
+	crash := wge.NewCivilian(10_000, 5).ApplyCrisis(1.0, 0.3).Rebels()
+	if !(10*gentle < crash) {
+		t.Errorf("crisis: expected crash (%d) to dwarf gentle decline (%d)\n", crash, gentle)
+	}
+}
+
+func TestCivilianIsExtinct(t *testing.T) {
+	if wge.NewCivilian(1, 5).IsExtinct() {
+		t.Errorf("extinct: expected living unit to not be extinct\n")
+	}
+
+	var p wge.Civilian
+	if err := json.Unmarshal([]byte(`{"loyal-citizens":0,"rebel-citizens":0,"tech-level":7}`), &p); err != nil {
+		t.Fatalf("extinct: expected nil, got %v\n", err)
+	}
+	if !p.IsExtinct() {
+		t.Fatalf("extinct: expected unit with no population to be extinct\n")
+	}
+	// an extinct unit stays extinct
+	q := p
+	for turn := 1; turn <= 10; turn++ {
+		q = q.ApplyTurn(0.1, 1.0)
+		if !q.Equal(p) {
+			t.Fatalf("extinct: turn %d: expected %+v, got %+v\n", turn, p, q)
+		}
+	}
+	// merging with an extinct unit returns the other unit
+	living := wge.NewCivilian(1_000, 3)
+	if got := p.Merge(living); !got.Equal(living) {
+		t.Errorf("extinct: merge: expected %+v, got %+v\n", living, got)
+	}
+	if got := living.Merge(p); !got.Equal(living) {
+		t.Errorf("extinct: merge: expected %+v, got %+v\n", living, got)
+	}
+}
+
+func TestCivilianMarshalJSONVerbose(t *testing.T) {
+	p, err := wge.NewCivilianBuilder().Loyal(750).Rebel(250).Tech(5).OnShip(true).Build()
+	if err != nil {
+		t.Fatalf("verbose: expected nil, got %v\n", err)
+	}
+	data, err := p.MarshalJSONVerbose()
+	if err != nil {
+		t.Fatalf("verbose: expected nil, got %v\n", err)
+	}
+	// the computed fields are present
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("verbose: expected nil, got %v\n", err)
+	}
+	for _, tc := range []struct {
+		field  string
+		expect float64
+	}{
+		{"population", 1_000},
+		{"rebel-fraction", 0.25},
+		{"food-needed", p.FoodNeeded()},
+		{"life-support-needed", p.LifeSupportNeeded()},
+	} {
+		if got, ok := fields[tc.field].(float64); !ok || !isClose(tc.expect, got) {
+			t.Errorf("verbose: %s: expected %v, got %v\n", tc.field, tc.expect, fields[tc.field])
+		}
+	}
+
+	// verbose output round-trips to the same civilian as the compact form
+	compact, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("verbose: compact: expected nil, got %v\n", err)
+	}
+	var fromVerbose, fromCompact wge.Civilian
+	if err := json.Unmarshal(data, &fromVerbose); err != nil {
+		t.Fatalf("verbose: unmarshal: expected nil, got %v\n", err)
+	}
+	if err := json.Unmarshal(compact, &fromCompact); err != nil {
+		t.Fatalf("verbose: unmarshal: expected nil, got %v\n", err)
+	}
+	if !fromVerbose.Equal(fromCompact) || !fromVerbose.Equal(p) {
+		t.Errorf("verbose: expected %+v, got %+v\n", fromCompact, fromVerbose)
+	}
+}
+
+func TestCivilianValidate(t *testing.T) {
+	for _, tc := range []struct {
+		id    int
+		data  string
+		field string
+	}{
+		{1, `{"loyal-citizens":-1,"rebel-citizens":0,"tech-level":3}`, "loyal-citizens"},
+		{2, `{"loyal-citizens":10,"rebel-citizens":-5,"tech-level":3}`, "rebel-citizens"},
+		{3, `{"loyal-citizens":10,"rebel-citizens":0,"tech-level":-1}`, "tech-level"},
+		{4, `{"loyal-citizens":10,"rebel-citizens":0,"tech-level":11}`, "tech-level"},
+		{5, `{"loyal":-10,"rebel":0,"tech-level":3}`, "loyal-citizens"},
+	} {
+		var p wge.Civilian
+		err := json.Unmarshal([]byte(tc.data), &p)
+		if err == nil {
+			t.Errorf("validate: %d: expected error, got nil\n", tc.id)
+		} else if !strings.Contains(err.Error(), tc.field) {
+			t.Errorf("validate: %d: expected error naming %q, got %v\n", tc.id, tc.field, err)
+		}
+	}
+
+	if err := wge.NewCivilian(1_000, 10).Validate(); err != nil {
+		t.Errorf("validate: expected nil, got %v\n", err)
+	}
+}
+
+func TestCivilianNormalize(t *testing.T) {
+	for _, tc := range []struct {
+		id        int
+		loyal     int
+		rebel     int
+		threshold float64
+		expect    int
+	}{
+		{1, 99_999, 1, 0.0001, 0},
+		{2, 99_999, 1, 0.00001, 1},
+		{3, 90_000, 10_000, 0.05, 10_000},
+		{4, 0, 0, 0.05, 0},
+		{5, 0, 3, 0.05, 3},
+	} {
+		p, err := wge.NewCivilianBuilder().Loyal(tc.loyal).Rebel(tc.rebel).Tech(5).Build()
+		if err != nil {
+			t.Fatalf("normalize: %d: expected nil, got %v\n", tc.id, err)
+		}
+		q := p.Normalize(tc.threshold)
+		if got := q.Rebels(); tc.expect != got {
+			t.Errorf("normalize: %d: expected %d rebels, got %d\n", tc.id, tc.expect, got)
+		}
+		if q.Population() != p.Population() {
+			t.Errorf("normalize: %d: expected population %d, got %d\n", tc.id, p.Population(), q.Population())
+		}
+	}
+}
+
+func TestCivilianMassAndVolume(t *testing.T) {
+	for _, tc := range []struct {
+		id        int
+		techLevel int
+		mass      float64
+		volume    float64
+	}{
+		{1, 0, 7.5, 5.0},
+		{2, 5, 10.0, 10.0},
+		{3, 10, 12.5, 15.0},
+	} {
+		p := wge.NewCivilian(1_000, tc.techLevel)
+		if got := p.Mass(); !isClose(tc.mass, got) {
+			t.Errorf("mass: %d: expected %8.4f, got %8.4f\n", tc.id, tc.mass, got)
+		}
+		if got := p.Volume(); !isClose(tc.volume, got) {
+			t.Errorf("volume: %d: expected %8.4f, got %8.4f\n", tc.id, tc.volume, got)
+		}
+	}
+}
+
+func TestCivilianSnapshot(t *testing.T) {
+	p, err := wge.NewCivilianBuilder().Loyal(800).Rebel(200).Tech(7).Build()
+	if err != nil {
+		t.Fatalf("snapshot: expected nil, got %v\n", err)
+	}
+	expect := wge.PopulationSnapshot{Loyal: 800, Rebel: 200, Tech: 7, Population: 1_000, Rebels: 200}
+	got := p.Snapshot()
+	if got != expect {
+		t.Errorf("snapshot: expected %+v, got %+v\n", expect, got)
+	}
+	if got.Population != p.Population() || got.Rebels != p.Rebels() || got.Tech != p.TechLevel() {
+		t.Errorf("snapshot: expected snapshot to match accessors, got %+v\n", got)
+	}
+}
+
+// batchUnits returns a slice of units with a mix of tech levels and locations.
+func batchUnits(n int) []wge.Civilian {
+	var units []wge.Civilian
+	for i := 0; i < n; i++ {
+		p := wge.NewCivilian(1_000+i*37, i%11)
+		if i%7 == 0 {
+			p = p.WithShip(true)
+		} else if i%5 == 0 {
+			p = p.WithColonyKind(wge.ResortColony)
+		}
+		units = append(units, p)
+	}
+	return units
+}
+
+func TestApplyTurnBatch(t *testing.T) {
+	units := append(batchUnits(100), wge.NewCivilian(0, 3))
+	got := wge.ApplyTurnBatch(units, 0.9, 0.7)
+	if len(got) != len(units) {
+		t.Fatalf("batch: expected %d units, got %d\n", len(units), len(got))
+	}
+	for i, p := range units {
+		if expect := p.ApplyTurn(0.9, 0.7); !expect.Equal(got[i]) {
+			t.Errorf("batch: %d: expected %+v, got %+v\n", i, expect, got[i])
+		}
+	}
+}
+
+func BenchmarkApplyTurnBatch(b *testing.B) {
+	units := batchUnits(1_000)
+	for i := 0; i < b.N; i++ {
+		_ = wge.ApplyTurnBatch(units, 0.9, 0.7)
+	}
+}
+
+func BenchmarkApplyTurnLoop(b *testing.B) {
+	units := batchUnits(1_000)
+	for i := 0; i < b.N; i++ {
+		results := make([]wge.Civilian, len(units))
+		for j, p := range units {
+			results[j] = p.ApplyTurn(0.9, 0.7)
+		}
+	}
+}
+
+func TestDistributeDeaths(t *testing.T) {
+	for _, tc := range []struct {
+		id           int
+		loyal, rebel int
+		deaths       int
+		loyalDeaths  int
+		rebelDeaths  int
+	}{
+		{1, 900, 100, 10, 9, 1},
+		// 3 rebels would die of 10 * 0.35 = 3.5; the extra death lands on loyal
+		{2, 650, 350, 10, 7, 3},
+		{3, 1, 1, 1, 1, 0},
+		{4, 0, 100, 10, 0, 10},
+		{5, 10, 10, 50, 10, 10},
+		{6, 10, 10, 0, 0, 0},
+	} {
+		loyalDeaths, rebelDeaths := wge.DistributeDeaths(tc.loyal, tc.rebel, tc.deaths)
+		if loyalDeaths != tc.loyalDeaths || rebelDeaths != tc.rebelDeaths {
+			t.Errorf("distribute: %d: expected %d/%d, got %d/%d\n", tc.id, tc.loyalDeaths, tc.rebelDeaths, loyalDeaths, rebelDeaths)
+		}
+	}
+
+	// ApplyTurn uses the same policy
+	p, err := wge.NewCivilianBuilder().Loyal(650).Rebel(350).Tech(10).OnShip(true).Build()
+	if err != nil {
+		t.Fatalf("distribute: expected nil, got %v\n", err)
+	}
+	deaths := int(1_000 * p.NaturalDeathRate(1.0, 0.5))
+	_, rebelDeaths := wge.DistributeDeaths(650, 350, deaths)
+	if got := p.ApplyTurn(1.0, 0.5).Rebels(); got != 350-rebelDeaths {
+		t.Errorf("distribute: applyTurn: expected %d rebels, got %d\n", 350-rebelDeaths, got)
+	}
+}
+
+func TestCivilianLifeSupportEnvironment(t *testing.T) {
+	for _, tc := range []struct {
+		id     int
+		p      wge.Civilian
+		expect float64
+	}{
+		{1, wge.NewCivilian(1_000, 5).WithShip(true), 5.0},
+		{2, wge.NewCivilian(1_000, 5).WithShip(true).WithEnvironment(wge.Toxic), 5.0},
+		{3, wge.NewCivilian(1_000, 5).WithColonyKind(wge.ClosedColony), 5.0},
+		{4, wge.NewCivilian(1_000, 5).WithColonyKind(wge.ClosedColony).WithEnvironment(wge.Hostile), 7.5},
+		{5, wge.NewCivilian(1_000, 5).WithColonyKind(wge.ClosedColony).WithEnvironment(wge.Toxic), 10.0},
+		{6, wge.NewCivilian(1_000, 5).WithEnvironment(wge.Toxic), 0},
+	} {
+		if got := tc.p.LifeSupportNeeded(); !isClose(tc.expect, got) {
+			t.Errorf("lifeSupport: environment: %d: expected %8.4f, got %8.4f\n", tc.id, tc.expect, got)
+		}
+	}
+	ship := wge.NewCivilian(1_000, 5).WithShip(true)
+	hostile := wge.NewCivilian(1_000, 5).WithColonyKind(wge.ClosedColony).WithEnvironment(wge.Hostile)
+	if !(ship.LifeSupportNeeded() < hostile.LifeSupportNeeded()) {
+		t.Errorf("lifeSupport: environment: expected ship < hostile closed colony\n")
+	}
+	// the environment survives a round trip
+	data, err := json.Marshal(hostile)
+	if err != nil {
+		t.Fatalf("lifeSupport: environment: expected nil, got %v\n", err)
+	}
+	var q wge.Civilian
+	if err := json.Unmarshal(data, &q); err != nil {
+		t.Fatalf("lifeSupport: environment: expected nil, got %v\n", err)
+	}
+	if !q.Equal(hostile) {
+		t.Errorf("lifeSupport: environment: expected %s to round trip\n", string(data))
+	}
+}
+
+func TestCivilianResidualGrowth(t *testing.T) {
+	// five people gain about a quarter of a person each turn
+	p := wge.NewCivilian(5, 10)
+	if got := p.ApplyTurn(2.0, 0.5).Population(); got != 5 {
+		t.Errorf("residual: turn 1: expected %d, got %d\n", 5, got)
+	}
+	for turn := 1; turn <= 10; turn++ {
+		p = p.ApplyTurn(2.0, 0.5)
+	}
+	if !(p.Population() > 5) {
+		t.Errorf("residual: expected slow-growing colony to grow, got %d\n", p.Population())
+	}
+
+	// the residual survives a round trip
+	data, err := json.Marshal(p.ApplyTurn(2.0, 0.5))
+	if err != nil {
+		t.Fatalf("residual: expected nil, got %v\n", err)
+	}
+	var q wge.Civilian
+	if err := json.Unmarshal(data, &q); err != nil {
+		t.Fatalf("residual: expected nil, got %v\n", err)
+	}
+	if !q.Equal(p.ApplyTurn(2.0, 0.5)) {
+		t.Errorf("residual: expected %s to round trip\n", string(data))
+	}
+
+	// a lone person on a ship eventually dies, leaving an extinct unit
+	ship := wge.NewCivilian(1, 10).WithShip(true)
+	for turn := 1; turn <= 500 && !ship.IsExtinct(); turn++ {
+		ship = ship.ApplyTurn(1.0, 0.5)
+	}
+	if !ship.IsExtinct() {
+		t.Errorf("residual: expected ship to die out, got %d\n", ship.Population())
+	}
+}
+
+func TestCivilianRateBreakdown(t *testing.T) {
+	for _, tc := range []struct {
+		id               int
+		p                wge.Civilian
+		standardOfLiving float64
+		pctCapacity      float64
+	}{
+		{1, wge.NewCivilian(1_000, 5), 1.0, 0.5},
+		{2, wge.NewCivilian(1_000, 2), 0.2, 0.97},
+		{3, wge.NewCivilian(1_000, 10).WithColonyKind(wge.ResortColony), 2.0, 0.1},
+		{4, wge.NewCivilian(1_000, 0), 0.3, 5.0},
+		{5, wge.NewCivilian(1_000, 7).WithShip(true), 1.3, 0.99},
+	} {
+		rb := tc.p.RateBreakdown(tc.standardOfLiving, tc.pctCapacity)
+		for _, rate := range []struct {
+			name   string
+			detail wge.RateDetail
+			expect float64
+		}{
+			{"birth", rb.Birth, tc.p.NaturalBirthRate(tc.standardOfLiving, tc.pctCapacity)},
+			{"death", rb.Death, tc.p.NaturalDeathRate(tc.standardOfLiving, tc.pctCapacity)},
+		} {
+			if !isClose(rate.expect, rate.detail.Rate) {
+				t.Errorf("breakdown: %d: %s: expected rate %8.4f%%, got %8.4f%%\n", tc.id, rate.name, 100*rate.expect, 100*rate.detail.Rate)
+			}
+			product := rate.detail.Base
+			for _, m := range rate.detail.Multipliers {
+				product *= m
+			}
+			if !isClose(rate.detail.Rate, product) {
+				t.Errorf("breakdown: %d: %s: expected product %8.4f%%, got %8.4f%%\n", tc.id, rate.name, 100*rate.detail.Rate, 100*product)
+			}
+		}
+	}
+}
+
+func TestCivilianRateBreakdownFactors(t *testing.T) {
+	for _, tc := range []struct {
+		id               int
+		p                wge.Civilian
+		standardOfLiving float64
+		pctCapacity      float64
+		birth, death     []string
+	}{
+		{1, wge.NewCivilian(1_000, 5), 1.0, 0.5,
+			[]string{wge.FactorTechBase},
+			[]string{wge.FactorTechBase}},
+		{2, wge.NewCivilian(1_000, 10).WithColonyKind(wge.ResortColony), 2.0, 0.1,
+			[]string{wge.FactorTechBase, wge.FactorResortBonus, wge.FactorStandardOfLiving, wge.FactorLivingSpace, wge.FactorLimit},
+			[]string{wge.FactorTechBase, wge.FactorStandardOfLiving}},
+		{3, wge.NewCivilian(1_000, 0), 1.0, 5.0,
+			[]string{wge.FactorTechBase, wge.FactorOvercrowding},
+			[]string{wge.FactorTechBase, wge.FactorOvercrowding}},
+	} {
+		rb := tc.p.RateBreakdown(tc.standardOfLiving, tc.pctCapacity)
+		for _, rate := range []struct {
+			name   string
+			detail wge.RateDetail
+			expect []string
+		}{
+			{"birth", rb.Birth, tc.birth},
+			{"death", rb.Death, tc.death},
+		} {
+			var labels []string
+			product := 1.0
+			for _, f := range rate.detail.Factors {
+				labels = append(labels, f.Label)
+				product *= f.Value
+			}
+			if strings.Join(labels, ",") != strings.Join(rate.expect, ",") {
+				t.Errorf("factors: %d: %s: expected %q, got %q\n", tc.id, rate.name, rate.expect, labels)
+			}
+			if !isClose(rate.detail.Rate, product) {
+				t.Errorf("factors: %d: %s: expected product %8.4f%%, got %8.4f%%\n", tc.id, rate.name, 100*rate.detail.Rate, 100*product)
+			}
+		}
+	}
+}
+
+func TestCivilianWillChange(t *testing.T) {
+	// an extinct unit never changes
+	if wge.NewCivilian(0, 5).WillChange(1.0, 0.5) {
+		t.Errorf("willChange: extinct: expected false, got true\n")
+	}
+	// at 90% capacity, tech 5 births and deaths are both 1%
+	balanced := wge.NewCivilian(1_000, 5)
+	if balanced.WillChange(1.0, 0.90) {
+		t.Errorf("willChange: balanced: expected false, got %+v\n", balanced.ApplyTurn(1.0, 0.90))
+	}
+	// a growing unit changes
+	if !wge.NewCivilian(1_000, 5).WillChange(1.0, 0.5) {
+		t.Errorf("willChange: growing: expected true, got false\n")
+	}
+	// and the answer is always consistent with ApplyTurn
+	for _, p := range batchUnits(50) {
+		for _, pct := range []float64{0.5, 0.9, 0.97} {
+			if got, expect := p.WillChange(1.0, pct), !p.ApplyTurn(1.0, pct).Equal(p); got != expect {
+				t.Errorf("willChange: %+v: %g: expected %v, got %v\n", p, pct, expect, got)
+			}
+		}
+	}
+}
+
+func TestCivilianMarshalBinary(t *testing.T) {
+	for _, tc := range []struct {
+		id int
+		p  wge.Civilian
+	}{
+		{1, wge.NewCivilian(0, 0)},
+		{2, wge.NewCivilian(1, 10)},
+		{3, wge.NewCivilian(127, 3)},
+		{4, wge.NewCivilian(128, 4)},
+		{5, wge.NewCivilian(1_000_000, 5).WithColonyKind(wge.ClosedColony).WithEnvironment(wge.Toxic)},
+		{6, wge.NewCivilian(250_000_000, 7).WithShip(true)},
+		{7, wge.NewCivilian(7, 5).ApplyTurn(1.0, 0.5)},
+		{8, wge.NewCivilian(500, 5).WithFoundedTurn(1_234)},
+		{9, wge.NewCivilian(5_000, 5).WithFoundedTurn(3).ApplyTurn(1.0, 0.5).ApplyResearch(12.5).WithFrozen(true)},
+		{10, wge.NewCivilian(3_000_000_000, 5)},
+		{11, wge.CivilianFromHeadcount(9_000_000_000, 4_000_000_000, 5)},
+	} {
+		data, err := tc.p.MarshalBinary()
+		if err != nil {
+			t.Errorf("marshalBinary: %d: expected nil, got %v\n", tc.id, err)
+			continue
+		}
+		js, _ := json.Marshal(tc.p)
+		if len(data) >= len(js) {
+			t.Errorf("marshalBinary: %d: expected fewer than %d bytes, got %d\n", tc.id, len(js), len(data))
+		}
+		var got wge.Civilian
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Errorf("unmarshalBinary: %d: expected nil, got %v\n", tc.id, err)
+		} else if got != tc.p { // Equal skips the birth history
+			t.Errorf("unmarshalBinary: %d: expected %+v, got %+v\n", tc.id, tc.p, got)
+		}
+	}
+
+	// a typical unit fits in a dozen bytes
+	if data, _ := wge.NewCivilian(1_000_000, 5).MarshalBinary(); len(data) > 12 {
+		t.Errorf("marshalBinary: size: expected <= 12, got %d\n", len(data))
+	}
+
+	data, _ := wge.NewCivilian(1_000_000, 5).MarshalBinary()
+	for _, tc := range []struct {
+		id   int
+		data []byte
+	}{
+		{1, nil},
+		{2, data[:1]},
+		{3, data[:len(data)-1]},
+		{4, append(append([]byte(nil), data...), 0)},
+		{5, []byte{0x80, 0x80}},
+		{6, []byte{1, 0, 11, 0}},
+		{7, []byte{1, 0, 5, 0x20, 0}},
+		{8, []byte{1, 0, 5, 0x00, 0x02, 1, 9}}, // history without the extension flag
+		{9, []byte{1, 0, 5, 0x80, 0x40}},       // unknown extension flag
+		{10, []byte{1, 0, 5, 0x80, 0x00}},      // empty extension
+		{11, []byte{1, 0, 5, 0x80, 0x02, 0}},   // no turns of history
+		{12, []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01, 0, 5, 0}},       // loyal past MaxInt
+		{13, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01, 0, 5, 0}}, // varint overflow
+	} {
+		var got wge.Civilian
+		if err := got.UnmarshalBinary(tc.data); err == nil {
+			t.Errorf("unmarshalBinary: corrupt %d: expected error, got %+v\n", tc.id, got)
+		}
+	}
+}
+
+func TestCivilianOptimalTaxRate(t *testing.T) {
+	p := wge.NewCivilian(10_000, 5)
+	happy, restive := p.OptimalTaxRate(1.5, 0.25), p.OptimalTaxRate(0.5, 0.25)
+	if !(happy > restive) {
+		t.Errorf("optimalTaxRate: expected happy %g > restive %g\n", happy, restive)
+	}
+	for _, tc := range []struct {
+		id    int
+		sol   float64
+		rate  float64
+		limit float64
+	}{
+		{1, 1.5, happy, 0.25},
+		{2, 0.5, restive, 0.25},
+	} {
+		if d := p.Discontent(tc.sol, tc.rate); d >= tc.limit {
+			t.Errorf("optimalTaxRate: %d: expected discontent < %g, got %g\n", tc.id, tc.limit, d)
+		}
+		if d := p.Discontent(tc.sol, tc.rate+0.001); d < tc.limit {
+			t.Errorf("optimalTaxRate: %d: expected %g to be the highest rate, got discontent %g at %g\n", tc.id, tc.rate, d, tc.rate+0.001)
+		}
+	}
+	// a population that is already in revolt can't be taxed
+	rebels, _ := wge.NewCivilianBuilder().Loyal(500).Rebel(500).Tech(5).Build()
+	if got := rebels.OptimalTaxRate(1.0, 0.25); got != 0 {
+		t.Errorf("optimalTaxRate: rebels: expected 0, got %g\n", got)
+	}
+}
+
+func TestCivilianFactions(t *testing.T) {
+	p, err := wge.NewCivilian(9_000, 5).WithFaction("red", 600)
+	if err != nil {
+		t.Fatalf("factions: red: expected nil, got %v\n", err)
+	}
+	q, err := wge.NewCivilian(9_000, 5).WithFaction("blue", 400)
+	if err != nil {
+		t.Fatalf("factions: blue: expected nil, got %v\n", err)
+	}
+	if q, err = q.WithFaction("", 100); err != nil {
+		t.Fatalf("factions: default: expected nil, got %v\n", err)
+	}
+
+	// merging keeps the factions distinct, and new rebels join the default faction
+	n := p.Merge(q)
+	if got := n.Faction("red"); got != 600 {
+		t.Errorf("factions: merge: red: expected 600, got %d\n", got)
+	}
+	if got := n.Faction("blue"); got != 400 {
+		t.Errorf("factions: merge: blue: expected 400, got %d\n", got)
+	}
+	if got := n.Faction(""); got != 101 {
+		t.Errorf("factions: merge: default: expected 101, got %d\n", got)
+	}
+	if got := n.Rebels(); got != 1_101 {
+		t.Errorf("factions: merge: rebels: expected 1101, got %d\n", got)
+	}
+	if got := n.Population(); got != 19_100 {
+		t.Errorf("factions: merge: population: expected 19100, got %d\n", got)
+	}
+	if got := len(n.Factions()); got != 2 {
+		t.Errorf("factions: merge: named: expected 2, got %d\n", got)
+	}
+
+	// deaths fall on every faction
+	dead := n.ApplyTurn(0.01, 5.0)
+	if !(dead.Faction("red") < 600 && dead.Faction("blue") < 400) {
+		t.Errorf("factions: deaths: expected fewer red and blue, got %+v\n", dead.Factions())
+	}
+
+	// factions survive json, and old data maps to the default faction
+	data, err := json.Marshal(n)
+	if err != nil {
+		t.Fatalf("factions: marshal: expected nil, got %v\n", err)
+	}
+	var got wge.Civilian
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("factions: unmarshal: expected nil, got %v\n", err)
+	} else if !got.Equal(n) {
+		t.Errorf("factions: json: expected %+v, got %+v\n", n, got)
+	}
+	if err := json.Unmarshal([]byte(`{"loyal-citizens":900,"rebel-citizens":100,"tech-level":2}`), &got); err != nil {
+		t.Fatalf("factions: legacy: expected nil, got %v\n", err)
+	} else if got.Faction("") != 100 || len(got.Factions()) != 0 {
+		t.Errorf("factions: legacy: expected 100 default rebels, got %d %+v\n", got.Faction(""), got.Factions())
+	}
+
+	// and the binary format
+	if data, err = n.MarshalBinary(); err != nil {
+		t.Fatalf("factions: marshalBinary: expected nil, got %v\n", err)
+	} else if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("factions: unmarshalBinary: expected nil, got %v\n", err)
+	} else if !got.Equal(n) {
+		t.Errorf("factions: binary: expected %+v, got %+v\n", n, got)
+	}
+
+	// a unit tracks at most four named factions
+	many := wge.NewCivilian(1_000, 5)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		if many, err = many.WithFaction(name, 10); err != nil {
+			t.Fatalf("factions: %s: expected nil, got %v\n", name, err)
+		}
+	}
+	if _, err = many.WithFaction("e", 10); err == nil {
+		t.Errorf("factions: e: expected error, got nil\n")
+	}
+}
+
+func TestCivilianAge(t *testing.T) {
+	var p wge.Aged = wge.NewCivilian(1_000, 5).WithFoundedTurn(12)
+	for _, tc := range []struct {
+		id     int
+		turn   int
+		expect int
+	}{
+		{1, 12, 0},
+		{2, 13, 1},
+		{3, 40, 28},
+		{4, 3, 0},
+	} {
+		if got := p.Age(tc.turn); got != tc.expect {
+			t.Errorf("age: %d: expected %d, got %d\n", tc.id, tc.expect, got)
+		}
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("age: marshal: expected nil, got %v\n", err)
+	}
+	var got wge.Civilian
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("age: unmarshal: expected nil, got %v\n", err)
+	} else if got.FoundedTurn() != 12 || got.Age(20) != 8 {
+		t.Errorf("age: json: expected founded 12 age 8, got %d %d\n", got.FoundedTurn(), got.Age(20))
+	}
+	// legacy data was founded on turn 0
+	if err := json.Unmarshal([]byte(`{"loyal-citizens":100,"rebel-citizens":0,"tech-level":2}`), &got); err != nil {
+		t.Fatalf("age: legacy: expected nil, got %v\n", err)
+	} else if got.FoundedTurn() != 0 {
+		t.Errorf("age: legacy: expected 0, got %d\n", got.FoundedTurn())
+	}
+	// merged units are as old as the older unit
+	if merged := wge.NewCivilian(100, 5).WithFoundedTurn(30).Merge(wge.NewCivilian(100, 5).WithFoundedTurn(12)); merged.FoundedTurn() != 12 {
+		t.Errorf("age: merge: expected 12, got %d\n", merged.FoundedTurn())
+	}
+}
+
+func TestCivilianFromMap(t *testing.T) {
+	p, err := wge.CivilianFromMap(map[string]any{
+		"loyal-citizens": "900",
+		"rebel-citizens": 100.0,
+		"tech-level":     " 4 ",
+		"colony-kind":    "closed",
+		"environment":    "toxic",
+		"on-ship":        "false",
+		"founded-turn":   json.Number("7"),
+		"factions":       map[string]any{"red": "25"},
+	})
+	if err != nil {
+		t.Fatalf("fromMap: expected nil, got %v\n", err)
+	}
+	if p.Population() != 1_025 || p.Faction("") != 100 || p.Faction("red") != 25 || p.TechLevel() != 4 {
+		t.Errorf("fromMap: expected 1025 with 100 + 25 rebels at tech 4, got %+v\n", p)
+	}
+	if !p.IsOnClosedColony() || p.FoundedTurn() != 7 {
+		t.Errorf("fromMap: expected closed colony founded on 7, got %+v\n", p)
+	}
+
+	// ToMap round-trips
+	if got, err := wge.CivilianFromMap(p.ToMap()); err != nil {
+		t.Errorf("toMap: expected nil, got %v\n", err)
+	} else if !got.Equal(p) {
+		t.Errorf("toMap: expected %+v, got %+v\n", p, got)
+	}
+
+	for _, tc := range []struct {
+		id int
+		m  map[string]any
+	}{
+		{1, map[string]any{"loyal-citizens": 900, "rebel-citizens": 100}},
+		{2, map[string]any{"loyal-citizens": 900, "tech-level": 4}},
+		{3, map[string]any{"loyal-citizens": "lots", "rebel-citizens": 0, "tech-level": 4}},
+		{4, map[string]any{"loyal-citizens": 9.5, "rebel-citizens": 0, "tech-level": 4}},
+		{5, map[string]any{"loyal-citizens": 900, "rebel-citizens": 0, "tech-level": 11}},
+		{6, map[string]any{"loyal-citizens": 900, "rebel-citizens": 0, "tech-level": 4, "colony-kind": "moon"}},
+		{7, map[string]any{"loyal-citizens": 1e19, "rebel-citizens": 0, "tech-level": 4}},
+		{8, map[string]any{"loyal-citizens": 900, "rebel-citizens": 0, "tech-level": 4, "founded-turn": "soon"}},
+	} {
+		if got, err := wge.CivilianFromMap(tc.m); err == nil {
+			t.Errorf("fromMap: %d: expected error, got %+v\n", tc.id, got)
+		}
+	}
+
+	// scripting layers pass large counts as floats
+	for _, v := range []any{3e9, "3000000000", int64(3_000_000_000)} {
+		if got, err := wge.CivilianFromMap(map[string]any{"loyal-citizens": v, "rebel-citizens": 0, "tech-level": 4}); err != nil {
+			t.Errorf("fromMap: %v: expected nil, got %v\n", v, err)
+		} else if got.Population() != 3_000_000_000 {
+			t.Errorf("fromMap: %v: expected 3000000000, got %d\n", v, got.Population())
+		}
+	}
+}
+
+func TestCivilianMergeWith(t *testing.T) {
+	p, _ := wge.NewCivilianBuilder().Loyal(8_000).Rebel(2_000).Tech(8).Build()
+	q := wge.NewCivilian(10_000, 2)
+
+	// the default config matches Merge
+	if got, expect := p.MergeWith(q, wge.DefaultRateConfig()), p.Merge(q); !got.Equal(expect) {
+		t.Errorf("mergeWith: default: expected %+v, got %+v\n", expect, got)
+	}
+	if got, expect := wge.MergeAllWith(wge.DefaultRateConfig(), p, q), wge.MergeAll(p, q); !got.Equal(expect) {
+		t.Errorf("mergeAllWith: default: expected %+v, got %+v\n", expect, got)
+	}
+
+	cfg := wge.DefaultRateConfig()
+	cfg.MergeMinRebels, cfg.MergeTechPenalty = 50, 0.10
+	mild, harsh := p.Merge(q), p.MergeWith(q, cfg)
+	// tech 5 after the merge, so the tech 8 rebels lose 3 levels
+	if got := mild.Rebels(); got != 2_060 {
+		t.Errorf("mergeWith: default: expected 2060 rebels, got %d\n", got)
+	}
+	if got := harsh.Rebels(); got != 2_600 {
+		t.Errorf("mergeWith: aggressive: expected 2600 rebels, got %d\n", got)
+	}
+	if got := wge.MergeAllWith(cfg, p, q).Rebels(); got != 2_600 {
+		t.Errorf("mergeAllWith: aggressive: expected 2600 rebels, got %d\n", got)
+	}
+	// however harsh the config, only the loyal citizens can turn rebel
+	cfg.MergeMinRebels = 1_000
+	if got := wge.NewCivilian(100, 5).MergeWith(wge.NewCivilian(200, 5), cfg); got.Rebels() != 300 || got.Population() != 300 {
+		t.Errorf("mergeWith: capped: expected 300/300, got %d/%d\n", got.Rebels(), got.Population())
+	}
+	if got := wge.MergeAllWith(cfg, wge.NewCivilian(100, 5), wge.NewCivilian(200, 5)); got.Rebels() != 300 || got.Population() != 300 {
+		t.Errorf("mergeAllWith: capped: expected 300/300, got %d/%d\n", got.Rebels(), got.Population())
+	}
+	cfg.MergeMinRebels = 50
+	// the minimum applies when no one loses tech
+	if got := wge.NewCivilian(100, 5).MergeWith(wge.NewCivilian(100, 5), cfg).Rebels(); got != 50 {
+		t.Errorf("mergeWith: minimum: expected 50 rebels, got %d\n", got)
+	}
+}
+
+func TestCivilianNetGrowthRate(t *testing.T) {
+	for _, tc := range []struct {
+		id   int
+		p    wge.Civilian
+		sol  float64
+		pct  float64
+		sign int
+	}{
+		{1, wge.NewCivilian(1_000, 5), 1.0, 0.5, 1},
+		{2, wge.NewCivilian(1_000, 10), 1.5, 0.97, 0},
+		{3, wge.NewCivilian(1_000, 2).WithColonyKind(wge.ResortColony), 0.3, 0.2, 1},
+		{4, wge.NewCivilian(1_000, 5).WithShip(true), 1.0, 0.5, -1},
+		{5, wge.NewCivilian(1_000, 5), 0.05, 2.5, -1}, // famine in an overcrowded colony
+	} {
+		expect := tc.p.NaturalBirthRate(tc.sol, tc.pct) - tc.p.NaturalDeathRate(tc.sol, tc.pct)
+		got := tc.p.NetGrowthRate(tc.sol, tc.pct)
+		if got != expect {
+			t.Errorf("netGrowthRate: %d: expected %g, got %g\n", tc.id, expect, got)
+		}
+		if tc.sign > 0 && !(got > 0) {
+			t.Errorf("netGrowthRate: %d: expected growth, got %g\n", tc.id, got)
+		} else if tc.sign < 0 && !(got < 0) {
+			t.Errorf("netGrowthRate: %d: expected decline, got %g\n", tc.id, got)
+		}
+	}
+}
+
+func TestCivilianRepair(t *testing.T) {
+	data := []byte(`{"loyal-citizens":900,"rebel-citizens":-50,"tech-level":255,"factions":{"red":-5,"blue":10}}`)
+	var strict wge.Civilian
+	if err := json.Unmarshal(data, &strict); err == nil {
+		t.Errorf("repair: strict: expected error, got nil\n")
+	}
+	p, changes, err := wge.DecodeCivilianRepaired(data)
+	if err != nil {
+		t.Fatalf("repair: expected nil, got %v\n", err)
+	}
+	if err := p.Validate(); err != nil {
+		t.Errorf("repair: validate: expected nil, got %v\n", err)
+	}
+	if len(changes) != 3 {
+		t.Errorf("repair: changes: expected 3, got %q\n", changes)
+	}
+	if p.Population() != 910 || p.Faction("") != 0 || p.Faction("blue") != 10 || p.TechLevel() != 10 {
+		t.Errorf("repair: expected 910 with 10 blue rebels at tech 10, got %+v\n", p)
+	}
+	// a valid unit needs no repair
+	q := wge.NewCivilian(1_000, 5)
+	if got, changes := q.Repair(); len(changes) != 0 || !got.Equal(q) {
+		t.Errorf("repair: valid: expected no changes, got %q\n", changes)
+	}
+	if _, _, err := wge.DecodeCivilianRepaired([]byte(`{"loyal-citizens":`)); err == nil {
+		t.Errorf("repair: truncated: expected error, got nil\n")
+	}
+}
+
+func TestCivilianDiff(t *testing.T) {
+	p, _ := wge.NewCivilianBuilder().Loyal(9_000).Rebel(1_000).Tech(5).Build()
+	if got := wge.Diff(p, p); got != (wge.CivilianDelta{}) {
+		t.Errorf("diff: self: expected zeros, got %+v\n", got)
+	}
+	// a crash in the standard of living turns 8% of the loyal citizens
+	crisis := p.ApplyCrisis(1.0, 0.6)
+	if got, expect := wge.Diff(p, crisis), (wge.CivilianDelta{Loyal: -720, Rebel: 720}); got != expect {
+		t.Errorf("diff: crisis: expected %+v, got %+v\n", expect, got)
+	}
+	// a turn of growth
+	next := p.ApplyTurn(1.0, 0.5)
+	got := wge.Diff(p, next)
+	if got.Population != next.Population()-p.Population() || got.Loyal+got.Rebel != got.Population || got.Tech != 0 {
+		t.Errorf("diff: turn: expected consistent deltas, got %+v\n", got)
+	}
+	if got := wge.Diff(wge.NewCivilian(100, 3), wge.NewCivilian(100, 5)); got.Tech != 2 {
+		t.Errorf("diff: tech: expected 2, got %d\n", got.Tech)
+	}
+}
+
+func TestCivilianMarshalProto(t *testing.T) {
+	rebels, _ := wge.NewCivilianBuilder().Loyal(1).Rebel(2).Tech(3).Build()
+	for _, tc := range []struct {
+		id     int
+		p      wge.Civilian
+		expect string
+	}{
+		// the expected bytes follow the protobuf encoding guide, where 150 in field 1 is 08 96 01
+		{1, wge.NewCivilian(150, 3), "08960118 03"},
+		{2, rebels, "0801 1002 1803"},
+		{3, wge.NewCivilian(0, 0), ""},
+		{4, wge.NewCivilian(300_000_000, 10), "0880c6868f01 180a"},
+		{5, wge.NewCivilian(3_000_000_000, 5), "0880bcc1960b 1805"},
+	} {
+		data, err := tc.p.MarshalProto()
+		if err != nil {
+			t.Errorf("marshalProto: %d: expected nil, got %v\n", tc.id, err)
+			continue
+		}
+		if got, expect := fmt.Sprintf("%x", data), strings.ReplaceAll(tc.expect, " ", ""); got != expect {
+			t.Errorf("marshalProto: %d: expected %s, got %s\n", tc.id, expect, got)
+		}
+		var got wge.Civilian
+		if err := got.UnmarshalProto(data); err != nil {
+			t.Errorf("unmarshalProto: %d: expected nil, got %v\n", tc.id, err)
+		} else if !got.Equal(tc.p) {
+			t.Errorf("unmarshalProto: %d: expected %+v, got %+v\n", tc.id, tc.p, got)
+		}
+	}
+
+	// unknown fields are skipped and field order doesn't matter
+	var got wge.Civilian
+	if err := got.UnmarshalProto([]byte{0x18, 0x03, 0x22, 0x01, 'x', 0x08, 0x96, 0x01}); err != nil {
+		t.Errorf("unmarshalProto: unknown: expected nil, got %v\n", err)
+	} else if !got.Equal(wge.NewCivilian(150, 3)) {
+		t.Errorf("unmarshalProto: unknown: expected 150 at tech 3, got %+v\n", got)
+	}
+	for _, tc := range []struct {
+		id   int
+		data []byte
+	}{
+		{1, []byte{0x08}},
+		{2, []byte{0x08, 0x96}},
+		{3, []byte{0x22, 0x05, 'x'}},
+		{4, []byte{0x18, 0x0b}},
+		{5, []byte{0x0b}},
+		{6, []byte{0x08, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}}, // past MaxInt64
+	} {
+		if err := got.UnmarshalProto(tc.data); err == nil {
+			t.Errorf("unmarshalProto: corrupt %d: expected error, got nil\n", tc.id)
+		}
+	}
+}
+
+func TestCivilianApplyTurnBiased(t *testing.T) {
+	p, _ := wge.NewCivilianBuilder().Loyal(8_000).Rebel(2_000).Tech(5).Build()
+	// a harsh turn in an overcrowded colony
+	const sol, pct = 0.5, 2.1
+	if got, expect := p.ApplyTurnBiased(sol, pct, 0), p.ApplyTurn(sol, pct); !got.Equal(expect) {
+		t.Errorf("applyTurnBiased: 0: expected %+v, got %+v\n", expect, got)
+	}
+	none, some, all := p.ApplyTurnBiased(sol, pct, 0), p.ApplyTurnBiased(sol, pct, 0.5), p.ApplyTurnBiased(sol, pct, 1)
+	if !(all.Rebels() < some.Rebels() && some.Rebels() < none.Rebels()) {
+		t.Errorf("applyTurnBiased: expected rebels %d < %d < %d\n", all.Rebels(), some.Rebels(), none.Rebels())
+	}
+	if none.Population() != all.Population() {
+		t.Errorf("applyTurnBiased: expected the same deaths, got %d and %d\n", none.Population(), all.Population())
+	}
+	// at full bias, loyal citizens only die once the rebels are gone
+	if all.Rebels() > 0 && all.Snapshot().Loyal < 8_000 {
+		t.Errorf("applyTurnBiased: 1: expected no loyal deaths, got %+v\n", all.Snapshot())
+	}
+}
+
+func TestCivilianAgePyramid(t *testing.T) {
+	// a new unit has no birth history, so nobody is young
+	if got := wge.NewCivilian(1_000, 5).AgePyramid(); got[0] != 0 || got[0]+got[1]+got[2] != 1_000 {
+		t.Errorf("agePyramid: new: expected no young and 1000 total, got %v\n", got)
+	}
+	// a steady unit and one that just had a baby boom
+	steady, boom := wge.NewCivilian(10_000, 5), wge.NewCivilian(10_000, 5)
+	for turn := 0; turn < 8; turn++ {
+		steady = steady.ApplyTurn(1.0, 0.90)
+	}
+	for turn := 0; turn < 8; turn++ {
+		boom = boom.ApplyTurn(2.0, 0.30)
+	}
+	for _, tc := range []struct {
+		name string
+		p    wge.Civilian
+	}{
+		{"steady", steady},
+		{"boom", boom},
+	} {
+		got := tc.p.AgePyramid()
+		if len(got) != 3 {
+			t.Fatalf("agePyramid: %s: expected 3 cohorts, got %v\n", tc.name, got)
+		} else if sum := got[0] + got[1] + got[2]; sum != tc.p.Population() {
+			t.Errorf("agePyramid: %s: expected %d total, got %d\n", tc.name, tc.p.Population(), sum)
+		}
+	}
+	young := func(p wge.Civilian) float64 {
+		return float64(p.AgePyramid()[0]) / float64(p.Population())
+	}
+	if !(young(boom) > young(steady)) {
+		t.Errorf("agePyramid: expected boom young share %g > steady %g\n", young(boom), young(steady))
+	}
+	// the birth history survives a round trip through json
+	data, err := json.Marshal(boom)
+	if err != nil {
+		t.Fatalf("agePyramid: marshal: expected nil, got %v\n", err)
+	} else if !strings.Contains(string(data), `"recent-births":[`) {
+		t.Errorf("agePyramid: marshal: expected recent-births, got %s\n", data)
+	}
+	var got wge.Civilian
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Errorf("agePyramid: unmarshal: expected nil, got %v\n", err)
+	} else if got != boom { // Equal skips the birth history
+		t.Errorf("agePyramid: unmarshal: expected %+v, got %+v\n", boom, got)
+	}
+}
+
+func TestCivilianFrozen(t *testing.T) {
+	p := wge.NewCivilian(1_000, 5).ApplyTurn(1.0, 0.5)
+	frozen := p.WithFrozen(true)
+	for turn := 0; turn < 100; turn++ {
+		frozen = frozen.ApplyTurn(1.0, 0.5)
+	}
+	if !frozen.Equal(p.WithFrozen(true)) {
+		t.Errorf("frozen: expected %+v, got %+v\n", p, frozen)
+	} else if frozen.WillChange(1.0, 0.5) {
+		t.Errorf("frozen: willChange: expected false, got true\n")
+	} else if got := frozen.TurnsToCapacity(1.0, 2_000); got != -1 {
+		t.Errorf("frozen: turnsToCapacity: expected -1, got %d\n", got)
+	}
+	// the frozen state survives json and binary round trips
+	var fromJSON, fromBinary wge.Civilian
+	if data, err := json.Marshal(frozen); err != nil {
+		t.Errorf("frozen: marshalJSON: expected nil, got %v\n", err)
+	} else if err := json.Unmarshal(data, &fromJSON); err != nil {
+		t.Errorf("frozen: unmarshalJSON: expected nil, got %v\n", err)
+	} else if !fromJSON.Equal(frozen) {
+		t.Errorf("frozen: json: expected %+v, got %+v\n", frozen, fromJSON)
+	}
+	if data, err := wge.NewCivilian(1_000, 5).WithFrozen(true).MarshalBinary(); err != nil {
+		t.Errorf("frozen: marshalBinary: expected nil, got %v\n", err)
+	} else if err := fromBinary.UnmarshalBinary(data); err != nil {
+		t.Errorf("frozen: unmarshalBinary: expected nil, got %v\n", err)
+	} else if !fromBinary.IsFrozen() {
+		t.Errorf("frozen: binary: expected frozen, got %+v\n", fromBinary)
+	}
+	// unfreezing resumes the simulation
+	if got, expect := frozen.WithFrozen(false).ApplyTurn(1.0, 0.5), p.ApplyTurn(1.0, 0.5); !got.Equal(expect) {
+		t.Errorf("frozen: thawed: expected %+v, got %+v\n", expect, got)
+	}
+	// events don't reach a frozen unit either
+	if got, deaths := frozen.ApplyEpidemic(1.0, 1.0); deaths != 0 || got != frozen {
+		t.Errorf("frozen: epidemic: expected %+v, 0, got %+v, %d\n", frozen, got, deaths)
+	}
+	if got := frozen.ApplyCrisis(1.0, 0.2); got != frozen {
+		t.Errorf("frozen: crisis: expected %+v, got %+v\n", frozen, got)
+	}
+	if got := frozen.ApplyUnrest(0.1, 0.9); got != frozen {
+		t.Errorf("frozen: unrest: expected %+v, got %+v\n", frozen, got)
+	}
+	if got := frozen.ApplyResearch(1_000_000); got != frozen {
+		t.Errorf("frozen: research: expected %+v, got %+v\n", frozen, got)
+	}
+	restive, _ := wge.NewCivilianBuilder().Loyal(600).Rebel(400).Tech(5).Build()
+	if got, pacified := restive.WithFrozen(true).Suppress(1.0); pacified != 0 || got != restive.WithFrozen(true) {
+		t.Errorf("frozen: suppress: expected %+v, 0, got %+v, %d\n", restive.WithFrozen(true), got, pacified)
+	}
+}
+
+func TestCivilianProductivityFactor(t *testing.T) {
+	for _, tc := range []struct {
+		id           int
+		loyal, rebel int
+		expect       float64
+	}{
+		{1, 1_000, 0, 1.0},
+		{2, 900, 100, 0.945},
+		{3, 500, 500, 0.625},
+		{4, 0, 1_000, 0},
+		{5, 0, 0, 1.0},
+	} {
+		p, err := wge.NewCivilianBuilder().Loyal(tc.loyal).Rebel(tc.rebel).Tech(5).Build()
+		if err != nil {
+			t.Fatalf("productivityFactor: %d: expected nil, got %v\n", tc.id, err)
+		}
+		if got := p.ProductivityFactor(); !isClose(tc.expect, got) {
+			t.Errorf("productivityFactor: %d: expected %g, got %g\n", tc.id, tc.expect, got)
+		}
+	}
+	// rebels cut the output of a colony
+	loyal := wge.NewColony(20_000, wge.NewCivilian(10_000, 5))
+	rebels, _ := wge.NewCivilianBuilder().Loyal(5_000).Rebel(5_000).Tech(5).Build()
+	restless := wge.NewColony(20_000, rebels)
+	if got, expect := restless.FoodProduced(10, 5), loyal.FoodProduced(10, 5)*0.625; !isClose(expect, got) {
+		t.Errorf("productivityFactor: food: expected %g, got %g\n", expect, got)
+	}
+	if got, expect := restless.GoodsProduced(10, 5), loyal.GoodsProduced(10, 5)*0.625; !isClose(expect, got) {
+		t.Errorf("productivityFactor: goods: expected %g, got %g\n", expect, got)
+	}
+}
+
+func TestCivilianHeadcount(t *testing.T) {
+	for _, tc := range []struct {
+		id                   int
+		people, rebels, tech int
+		loyal, rebel         int
+		quantity             float64
+	}{
+		{1, 1_250, 0, 5, 1_250, 0, 12.5},
+		{2, 1_000, 250, 3, 750, 250, 10},
+		{3, 99, 99, 5, 0, 99, 0.99},
+		{4, 100, 150, 5, 0, 100, 1},
+		{5, 100, -5, 5, 100, 0, 1},
+		{6, 0, 0, 5, 0, 0, 0},
+	} {
+		p := wge.CivilianFromHeadcount(tc.people, tc.rebels, tc.tech)
+		if loyal, rebel := p.Headcount(); loyal != tc.loyal || rebel != tc.rebel {
+			t.Errorf("headcount: %d: expected %d/%d, got %d/%d\n", tc.id, tc.loyal, tc.rebel, loyal, rebel)
+		}
+		if got := p.Quantity(); !isClose(tc.quantity, got) {
+			t.Errorf("headcount: %d: quantity: expected %g, got %g\n", tc.id, tc.quantity, got)
+		}
+		if got := p.TechLevel(); got != tc.tech {
+			t.Errorf("headcount: %d: tech: expected %d, got %d\n", tc.id, tc.tech, got)
+		}
+	}
+}
+
+func TestCivilianApplyTurnCapped(t *testing.T) {
+	p := wge.NewCivilian(10_000, 5)
+	// at 25% capacity the birth rate is well above the death rate
+	if got, expect := p.ApplyTurnCapped(1.0, 0.25, math.MaxInt32), p.ApplyTurn(1.0, 0.25); !got.Equal(expect) {
+		t.Errorf("applyTurnCapped: uncapped: expected %+v, got %+v\n", expect, got)
+	}
+	// births only replace the deaths at the cap
+	if got := p.ApplyTurnCapped(1.0, 0.25, 10_000).Population(); got != 10_000 {
+		t.Errorf("applyTurnCapped: at cap: expected 10000, got %d\n", got)
+	}
+	// and there are none over the cap
+	if got := p.ApplyTurnCapped(1.0, 0.25, 9_000).Population(); got >= 10_000 {
+		t.Errorf("applyTurnCapped: over cap: expected deaths only, got %d\n", got)
+	}
+}
+
+func TestCivilianApplyResearch(t *testing.T) {
+	p := wge.NewCivilian(1_000, 5)
+	if got := p.ResearchThreshold(); !isClose(600, got) {
+		t.Errorf("applyResearch: threshold: expected 600, got %g\n", got)
+	}
+	// points accumulate until they reach the threshold
+	p = p.ApplyResearch(250).ApplyResearch(250)
+	if p.TechLevel() != 5 || !isClose(500, p.ResearchPoints()) {
+		t.Errorf("applyResearch: partial: expected tech 5 with 500 points, got %d with %g\n", p.TechLevel(), p.ResearchPoints())
+	}
+	// the accumulator is saved with the unit
+	var got wge.Civilian
+	if data, err := json.Marshal(p); err != nil {
+		t.Errorf("applyResearch: marshal: expected nil, got %v\n", err)
+	} else if err := json.Unmarshal(data, &got); err != nil {
+		t.Errorf("applyResearch: unmarshal: expected nil, got %v\n", err)
+	} else if !got.Equal(p) {
+		t.Errorf("applyResearch: json: expected %+v, got %+v\n", p, got)
+	}
+	if data, err := p.MarshalBinary(); err != nil {
+		t.Errorf("applyResearch: marshalBinary: expected nil, got %v\n", err)
+	} else if err := got.UnmarshalBinary(data); err != nil {
+		t.Errorf("applyResearch: unmarshalBinary: expected nil, got %v\n", err)
+	} else if !got.Equal(p) {
+		t.Errorf("applyResearch: binary: expected %+v, got %+v\n", p, got)
+	}
+	// reaching the threshold advances one level and resets the points
+	p = p.ApplyResearch(5_000)
+	if p.TechLevel() != 6 || p.ResearchPoints() != 0 {
+		t.Errorf("applyResearch: advance: expected tech 6 with 0 points, got %d with %g\n", p.TechLevel(), p.ResearchPoints())
+	}
+	// tech never goes past 10
+	for i := 0; i < 20; i++ {
+		p = p.ApplyResearch(5_000)
+	}
+	if p.TechLevel() != 10 || p.ResearchPoints() != 0 {
+		t.Errorf("applyResearch: max: expected tech 10 with 0 points, got %d with %g\n", p.TechLevel(), p.ResearchPoints())
+	}
+}
+
+func TestCivilianRadicalization(t *testing.T) {
+	p, _ := wge.NewCivilianBuilder().Loyal(6_000).Rebel(4_000).Tech(5).Build()
+	// a fresh crackdown pacifies half the rebels
+	if _, got := p.Suppress(0.5); got != 2_000 {
+		t.Errorf("radicalization: fresh: expected 2000 pacified, got %d\n", got)
+	}
+	// left restive, the rebels get harder to pacify every turn
+	prev := 2_000
+	for turn := 1; turn <= 5; turn++ {
+		p = p.ApplyUnrest(0.5, 0.3)
+		_, got := p.Suppress(0.5)
+		if !(got < prev) {
+			t.Errorf("radicalization: turn %d: expected fewer than %d pacified, got %d\n", turn, prev, got)
+		}
+		prev = got
+	}
+	if got := p.Radicalization(); !isClose(0.5, got) {
+		t.Errorf("radicalization: expected 0.5, got %g\n", got)
+	}
+	// the level is saved with the unit
+	var got wge.Civilian
+	if data, err := json.Marshal(p); err != nil {
+		t.Errorf("radicalization: marshal: expected nil, got %v\n", err)
+	} else if !strings.Contains(string(data), `"radicalization":0.5`) {
+		t.Errorf("radicalization: marshal: expected radicalization, got %s\n", data)
+	} else if err := json.Unmarshal(data, &got); err != nil {
+		t.Errorf("radicalization: unmarshal: expected nil, got %v\n", err)
+	} else if !got.Equal(p) {
+		t.Errorf("radicalization: json: expected %+v, got %+v\n", p, got)
+	}
+	if data, err := p.MarshalBinary(); err != nil {
+		t.Errorf("radicalization: marshalBinary: expected nil, got %v\n", err)
+	} else if err := got.UnmarshalBinary(data); err != nil {
+		t.Errorf("radicalization: unmarshalBinary: expected nil, got %v\n", err)
+	} else if !got.Equal(p) {
+		t.Errorf("radicalization: binary: expected %+v, got %+v\n", p, got)
+	}
+	// addressing the grievances lets it ease, and the hardened rebels
+	// drift back to loyalty at half the rate of fresh ones
+	calm := p.ApplyUnrest(3.0, 0)
+	if got := calm.Radicalization(); !isClose(0.45, got) {
+		t.Errorf("radicalization: calm: expected 0.45, got %g\n", got)
+	}
+	if got := calm.Rebels(); got != 3_900 {
+		t.Errorf("radicalization: calm: expected 3900 rebels, got %d\n", got)
+	} else if got := calm.Population(); got != 10_000 {
+		t.Errorf("radicalization: calm: expected 10000 people, got %d\n", got)
+	}
+	fresh, _ := wge.NewCivilianBuilder().Loyal(6_000).Rebel(4_000).Tech(5).Build()
+	if got := fresh.ApplyUnrest(3.0, 0).Rebels(); got != 3_800 {
+		t.Errorf("radicalization: fresh: expected 3800 rebels, got %d\n", got)
+	}
+}
+
+func TestCivilianGarrison(t *testing.T) {
+	p, _ := wge.NewCivilianBuilder().Loyal(10_000).Rebel(2_000).Tech(5).Build()
+	garrisoned, err := p.WithGarrison(10_000)
+	if err != nil {
+		t.Fatalf("garrison: expected nil, got %v\n", err)
+	} else if _, err := p.WithGarrison(10_001); err == nil {
+		t.Errorf("garrison: too many: expected error, got nil\n")
+	}
+	// only the rebels are free to migrate
+	to := wge.NewColony(100_000, wge.NewCivilian(1_000, 5))
+	free := wge.MigrationFlow(wge.NewColony(20_000, wge.NewCivilian(2_000, 5)), to, 0.5, 2.0)
+	if got := wge.MigrationFlow(wge.NewColony(20_000, garrisoned), to, 0.5, 2.0); got != free {
+		t.Errorf("garrison: migration: expected %d, got %d\n", free, got)
+	}
+	loyal, _ := wge.NewCivilian(5_000, 5).WithGarrison(5_000)
+	if got := wge.MigrationFlow(wge.NewColony(20_000, loyal), to, 0.5, 2.0); got != 0 {
+		t.Errorf("garrison: migration: expected 0, got %d\n", got)
+	}
+	// and no defectors in a crisis or a merge
+	if got := garrisoned.ApplyCrisis(1.0, 0.2).Rebels(); got != 2_000 {
+		t.Errorf("garrison: crisis: expected 2000 rebels, got %d\n", got)
+	}
+	if got := p.ApplyCrisis(1.0, 0.2).Rebels(); got == 2_000 {
+		t.Errorf("garrison: crisis: expected defectors without a garrison, got none\n")
+	}
+	// merging with a lower tech unit would turn 20 loyal citizens, but only
+	// the 10 newcomers are outside the garrison
+	q, _ := wge.NewCivilianBuilder().Loyal(10).Rebel(100).Tech(3).Build()
+	if got := p.Merge(q).Rebels(); got != 2_120 {
+		t.Errorf("garrison: merge: expected 2120 rebels without a garrison, got %d\n", got)
+	}
+	if got := garrisoned.Merge(q); got.Rebels() != 2_110 || got.Garrison() != 10_000 {
+		t.Errorf("garrison: merge: expected 2110 rebels and 10000 garrison, got %d and %d\n", got.Rebels(), got.Garrison())
+	}
+	// the garrison stays behind when the unit splits
+	if rest, part, err := garrisoned.Split(1_000); err != nil {
+		t.Errorf("garrison: split: expected nil, got %v\n", err)
+	} else if rest.Garrison() != 10_000 || rest.Rebels() != 1_000 {
+		t.Errorf("garrison: split: expected 10000 garrison and 1000 rebels, got %d and %d\n", rest.Garrison(), rest.Rebels())
+	} else if part.Garrison() != 0 || part.Rebels() != 1_000 || part.Population() != 1_000 {
+		t.Errorf("garrison: split: expected 1000 rebels and no garrison, got %+v\n", part)
+	}
+	if _, _, err := garrisoned.Split(2_001); err == nil {
+		t.Errorf("garrison: split: too many: expected error, got nil\n")
+	}
+	// the garrison still eats and is saved with the unit
+	if got, expect := garrisoned.FoodNeeded(), p.FoodNeeded(); got != expect {
+		t.Errorf("garrison: food: expected %g, got %g\n", expect, got)
+	}
+	var got wge.Civilian
+	if data, err := json.Marshal(garrisoned); err != nil {
+		t.Errorf("garrison: marshal: expected nil, got %v\n", err)
+	} else if err := json.Unmarshal(data, &got); err != nil {
+		t.Errorf("garrison: unmarshal: expected nil, got %v\n", err)
+	} else if got.Garrison() != 10_000 {
+		t.Errorf("garrison: json: expected 10000, got %d\n", got.Garrison())
+	}
+	// older saves have no garrison
+	if err := json.Unmarshal([]byte(`{"loyal-citizens":100,"rebel-citizens":0,"tech-level":5}`), &got); err != nil {
+		t.Errorf("garrison: old: expected nil, got %v\n", err)
+	} else if got.Garrison() != 0 {
+		t.Errorf("garrison: old: expected 0, got %d\n", got.Garrison())
+	}
+}
+
+func TestCivilianMarshalJSONIndent(t *testing.T) {
+	p, _ := wge.NewCivilianBuilder().Loyal(900).Rebel(100).Tech(4).Build()
+	p = p.WithFoundedTurn(3).ApplyTurn(1.0, 0.5).WithColonyKind(wge.ResortColony)
+	compact, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("marshalJSONIndent: compact: expected nil, got %v\n", err)
+	}
+	pretty, err := p.MarshalJSONIndent("", "  ")
+	if err != nil {
+		t.Fatalf("marshalJSONIndent: pretty: expected nil, got %v\n", err)
+	} else if !strings.Contains(string(pretty), "\n  \"loyal-citizens\": ") {
+		t.Errorf("marshalJSONIndent: pretty: expected indented fields, got %s\n", pretty)
+	}
+	for _, tc := range []struct {
+		name string
+		data []byte
+	}{
+		{"compact", compact},
+		{"pretty", pretty},
+	} {
+		var got wge.Civilian
+		if err := json.Unmarshal(tc.data, &got); err != nil {
+			t.Errorf("marshalJSONIndent: %s: expected nil, got %v\n", tc.name, err)
+		} else if !got.Equal(p) {
+			t.Errorf("marshalJSONIndent: %s: expected %+v, got %+v\n", tc.name, p, got)
+		}
+	}
+}
+
+func TestCivilianTurnsToExtinction(t *testing.T) {
+	p := wge.NewCivilian(10_000, 5)
+	// with no food at all the colony is doomed
+	doomed := p.TurnsToExtinction(1.0, 0.9, 0)
+	if doomed <= 0 {
+		t.Errorf("turnsToExtinction: doomed: expected a positive count, got %d\n", doomed)
+	}
+	// a fed colony can still die out if deaths outpace births
+	if got := p.TurnsToExtinction(1.0, 0.99, p.FoodNeeded()); !(got > doomed) {
+		t.Errorf("turnsToExtinction: crowded: expected more than %d, got %d\n", doomed, got)
+	}
+	// half rations shrink the colony until the food is enough
+	if got := p.TurnsToExtinction(1.0, 0.9, p.FoodNeeded()*0.5); got != -1 {
+		t.Errorf("turnsToExtinction: recovering: expected -1, got %d\n", got)
+	}
+	if got := wge.NewCivilian(0, 5).TurnsToExtinction(1.0, 0.9, 0); got != 0 {
+		t.Errorf("turnsToExtinction: extinct: expected 0, got %d\n", got)
+	}
+	// the shortage kills half of the unfed
+	if got, deaths := p.ApplyFoodShortage(p.FoodNeeded() * 0.5); deaths != 2_500 || got.Population() != 7_500 {
+		t.Errorf("applyFoodShortage: expected 2500 deaths, got %d and %d\n", deaths, got.Population())
+	}
+	if _, deaths := p.ApplyFoodShortage(p.FoodNeeded()); deaths != 0 {
+		t.Errorf("applyFoodShortage: fed: expected 0 deaths, got %d\n", deaths)
+	}
+}
+
+func TestCivilianApplyTurnAt(t *testing.T) {
+	// at 160% capacity the crowding bands double the death rate
+	p := wge.NewCivilian(100_000, 5).WithFoundedTurn(10)
+	established := p.ApplyTurn(1.0, 1.6).Population()
+	for _, tc := range []struct {
+		id     int
+		turn   int
+		expect func(got int) bool
+	}{
+		{1, 10, func(got int) bool { return got > established }},
+		{2, 12, func(got int) bool { return got > established }},
+		{3, 15, func(got int) bool { return got == established }},
+		{4, 100, func(got int) bool { return got == established }},
+	} {
+		if got := p.ApplyTurnAt(tc.turn, 1.0, 1.6).Population(); !tc.expect(got) {
+			t.Errorf("applyTurnAt: %d: established %d, got %d\n", tc.id, established, got)
+		}
+	}
+	// newer units are spared more
+	if fresh, older := p.ApplyTurnAt(10, 1.0, 1.6).Population(), p.ApplyTurnAt(12, 1.0, 1.6).Population(); !(fresh > older) {
+		t.Errorf("applyTurnAt: decay: expected %d > %d\n", fresh, older)
+	}
+	// the grace period doesn't matter to an uncrowded unit
+	if got, expect := p.ApplyTurnAt(10, 1.0, 0.5), p.ApplyTurn(1.0, 0.5); !got.Equal(expect) {
+		t.Errorf("applyTurnAt: uncrowded: expected %d, got %d\n", expect.Population(), got.Population())
+	}
+	// nor do units founded after the current turn
+	if got, expect := p.ApplyTurnAt(5, 1.0, 1.6), p.ApplyTurn(1.0, 1.6); !got.Equal(expect) {
+		t.Errorf("applyTurnAt: future: expected %d, got %d\n", expect.Population(), got.Population())
+	}
+	// legacy units without a founding turn get no grace
+	legacy := wge.NewCivilian(100_000, 5)
+	if got, expect := legacy.ApplyTurnAt(2, 1.0, 1.6), legacy.ApplyTurn(1.0, 1.6); !got.Equal(expect) {
+		t.Errorf("applyTurnAt: legacy: expected %d, got %d\n", expect.Population(), got.Population())
+	}
+}
+
+func TestCivilianConscript(t *testing.T) {
+	p := wge.CivilianFromHeadcount(10_000, 2_000, 5)
+	// rebels can't be drafted
+	if _, _, err := p.Conscript(8_001); err == nil {
+		t.Errorf("conscript: insufficient: expected error, got nil\n")
+	}
+	if _, _, err := p.Conscript(-1); err == nil {
+		t.Errorf("conscript: negative: expected error, got nil\n")
+	}
+	rest, sld, err := p.Conscript(1_000)
+	if err != nil {
+		t.Fatalf("conscript: expected no error, got %v\n", err)
+	}
+	if got := sld.Population(); got != 1_000 {
+		t.Errorf("conscript: soldiers: expected 1000, got %d\n", got)
+	}
+	if got := sld.TechLevel(); got != 5 {
+		t.Errorf("conscript: tech: expected 5, got %d\n", got)
+	}
+	if got := rest.Population(); got != 9_000 {
+		t.Errorf("conscript: population: expected 9000, got %d\n", got)
+	}
+	if got := rest.Rebels(); got != 2_050 {
+		t.Errorf("conscript: rebels: expected 2050, got %d\n", got)
+	}
+	before, after := float64(p.Rebels())/float64(p.Population()), float64(rest.Rebels())/float64(rest.Population())
+	if !(after > before) {
+		t.Errorf("conscript: fraction: expected more than %f, got %f\n", before, after)
+	}
+}
+
+func TestCivilianMergeLossless(t *testing.T) {
+	p, q := wge.NewCivilian(1_000, 5), wge.NewCivilian(2_000, 5)
+	// by default every merge turns at least one citizen rebel
+	if got := p.Merge(q).Rebels(); got != 1 {
+		t.Errorf("lossless: default: expected 1, got %d\n", got)
+	}
+	cfg := wge.DefaultRateConfig()
+	cfg.MergeLossless = true
+	for _, tc := range []struct {
+		id     int
+		p, q   wge.Civilian
+		expect int
+	}{
+		{1, p, q, 0},
+		{2, p, wge.NewCivilian(2_000, 4), 1},                // tech mismatch
+		{3, p, wge.CivilianFromHeadcount(2_000, 10, 5), 11}, // q has rebels
+	} {
+		if got := tc.p.MergeWith(tc.q, cfg).Rebels(); got != tc.expect {
+			t.Errorf("lossless: %d: expected %d, got %d\n", tc.id, tc.expect, got)
+		}
+	}
+	if got := wge.MergeAllWith(cfg, p, q, wge.NewCivilian(500, 5)).Rebels(); got != 0 {
+		t.Errorf("lossless: all: expected 0, got %d\n", got)
+	}
+	if got := wge.MergeAllWith(cfg, p, q, wge.NewCivilian(500, 7)).Rebels(); got != 1 {
+		t.Errorf("lossless: all: mismatch: expected 1, got %d\n", got)
+	}
+}
+
+func TestCivilianSplitRebels(t *testing.T) {
+	p, err := wge.CivilianFromHeadcount(10_000, 1_000, 5).WithFaction("reds", 500)
+	if err != nil {
+		t.Fatalf("splitRebels: faction: expected no error, got %v\n", err)
+	}
+	if _, _, err := p.SplitRebels(1_501); err == nil {
+		t.Errorf("splitRebels: too many: expected error, got nil\n")
+	}
+	if _, _, err := p.SplitRebels(-1); err == nil {
+		t.Errorf("splitRebels: negative: expected error, got nil\n")
+	}
+	kept, exiled, err := p.SplitRebels(600)
+	if err != nil {
+		t.Fatalf("splitRebels: expected no error, got %v\n", err)
+	}
+	if got := kept.Population() - kept.Rebels(); got != 9_000 {
+		t.Errorf("splitRebels: loyal: expected 9000, got %d\n", got)
+	}
+	if got := kept.Rebels(); got != 900 {
+		t.Errorf("splitRebels: kept: expected 900, got %d\n", got)
+	}
+	if exiled.Population() != 600 || exiled.Rebels() != 600 {
+		t.Errorf("splitRebels: exiled: expected 600/600, got %d/%d\n", exiled.Population(), exiled.Rebels())
+	}
+	if got := kept.Faction("reds") + exiled.Faction("reds"); got != 500 {
+		t.Errorf("splitRebels: reds: expected 500, got %d\n", got)
+	}
+	if got := exiled.TechLevel(); got != 5 {
+		t.Errorf("splitRebels: tech: expected 5, got %d\n", got)
+	}
+}
+
+func TestCivilianClone(t *testing.T) {
+	p, err := wge.CivilianFromHeadcount(10_000, 1_000, 5).WithFaction("reds", 200)
+	if err != nil {
+		t.Fatalf("clone: faction: expected nil, got %v\n", err)
+	}
+	original := p
+	clone := p.Clone()
+	if clone != p {
+		t.Fatalf("clone: expected %+v, got %+v\n", p, clone)
+	}
+	// overwrite the clone in place
+	if err := json.Unmarshal([]byte(`{"loyal-citizens":5,"rebel-citizens":1,"tech-level":2,"factions":{"blues":1}}`), &clone); err != nil {
+		t.Fatalf("clone: unmarshal: expected nil, got %v\n", err)
+	}
+	if clone.Population() != 7 || clone.Faction("blues") != 1 {
+		t.Errorf("clone: expected the clone to change, got %+v\n", clone)
+	}
+	if p != original || p.Faction("reds") != 200 || p.Faction("blues") != 0 {
+		t.Errorf("clone: original: expected %+v, got %+v\n", original, p)
+	}
+}
@@ -0,0 +1,1076 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Colony is a group of units sharing the same living space.
+type Colony struct {
+	capacity      int // number of people the colony can hold
+	capacityLimit int // hard cap on capacity; zero means no cap
+	members       []Unit
+	frozen        bool    // frozen colonies skip simulation
+	turn          int     // sequence number of the last turn applied
+	standard      float64 // standard of living used by the last turn
+	planet        string  // id of the planet the colony is on; empty if unknown
+	// immigration is the cap on people arriving each turn.
+	immigration struct {
+		quota  int         // zero means no quota
+		policy QuotaPolicy // what happens to people over the quota
+		intake int         // people admitted this turn
+	}
+}
+
+// auxColony is a helper to convert to/from json.
+// used to implement json.Marshaler and json.Unmarshaler interfaces.
+type auxColony struct {
+	Capacity      int         `json:"capacity"`
+	CapacityLimit int         `json:"capacity-limit,omitempty"`
+	Quota         int         `json:"immigration-quota,omitempty"`
+	QuotaPolicy   QuotaPolicy `json:"quota-policy,omitempty"`
+	Frozen        bool        `json:"frozen,omitempty"`
+	Turn          int         `json:"turn,omitempty"`
+	Standard      float64     `json:"standard-of-living,omitempty"`
+	Planet        string      `json:"planet,omitempty"`
+	Members       []auxUnit   `json:"members"`
+}
+
+// NewColony returns a colony with the given capacity and members.
+func NewColony(capacity int, members ...Unit) Colony {
+	return Colony{
+		capacity: capacity,
+		members:  append([]Unit(nil), members...),
+	}
+}
+
+// AddUnit returns a copy of the colony with the unit added after the
+// existing members. The capacity is not changed, and the original colony
+// is not modified.
+func (c Colony) AddUnit(u Unit) Colony {
+	members := make([]Unit, 0, len(c.members)+1)
+	c.members = append(append(members, c.members...), u)
+	return c
+}
+
+// ApplyTurn returns the colony after one turn of births and deaths.
+// Every civilian member uses the standard of living and the fraction of
+// capacity the colony was at when the turn started, and then goes through
+// a turn of unrest at that standard; the colony collects no taxes, so the
+// discontent comes from the rebels alone. Other population groups, such
+// as soldiers, don't reproduce but die at their natural death rate,
+// truncated; members that aren't population groups don't change. The
+// immigration intake is reset for the new turn, the turn sequence number
+// is incremented, and the standard of living is kept for RenderDashboard.
+// A frozen colony is returned unchanged except for the turn sequence
+// number. ApplyTurn doesn't know the game turn, so newly founded members
+// get no grace period; see ApplyTurnAt.
+func (c Colony) ApplyTurn(standardOfLiving float64) Colony {
+	return c.applyTurn(0, false, standardOfLiving)
+}
+
+// ApplyTurnAt is ApplyTurn for the given game turn, the same turn numbers
+// used for the founding turns of the members. Civilian members go through
+// Civilian.ApplyTurnAt, so members founded on a recent turn get their
+// grace period from overcrowding. The game turn is separate from the
+// colony's own turn sequence number, which is still incremented.
+func (c Colony) ApplyTurnAt(currentTurn int, standardOfLiving float64) Colony {
+	return c.applyTurn(currentTurn, true, standardOfLiving)
+}
+
+// ApplyTurnOnPlanet is ApplyTurn for a colony on a planet whose carrying
+// capacity is an absolute limit on the population of the colony.
+// Civilian members grow as in Civilian.ApplyTurnCapped, in order, until
+// the colony reaches the limit; after that only deaths apply. The crowding that sets the rates still comes
+// from the capacity of the colony, not from the planet. Unrest and the
+// deaths of other population groups apply as in ApplyTurn.
+func (c Colony) ApplyTurnOnPlanet(standardOfLiving float64, pl Planet) Colony {
+	c.turn++
+	if c.frozen {
+		return c
+	}
+	c.standard, c.immigration.intake = standardOfLiving, 0
+	pctCapacity := c.PctCapacity()
+	room := pl.CarryingCapacity() - c.Population()
+	members := make([]Unit, len(c.members))
+	for i, u := range c.members {
+		if p, ok := u.(Civilian); ok {
+			next := p.ApplyTurnCapped(standardOfLiving, pctCapacity, p.Population()+room)
+			room -= next.Population() - p.Population()
+			u = next.ApplyUnrest(standardOfLiving, 0)
+		} else if pg, ok := u.(PopulationGroup); ok {
+			u = killUnit(u, int(float64(pg.Population())*pg.NaturalDeathRate(standardOfLiving, pctCapacity)))
+		}
+		members[i] = u
+	}
+	c.members = members
+	return c
+}
+
+// ApplyTurnOnce is ApplyTurn for engines that replay events. It applies
+// the turn only if it is the next one in sequence, that is, one more than
+// Turn. A turn that has already been applied returns the colony unchanged
+// along with an error, so applying the same turn twice leaves the colony
+// as if it were applied once. Skipping ahead also returns an error.
+func (c Colony) ApplyTurnOnce(turn int, standardOfLiving float64) (Colony, error) {
+	if turn <= c.turn {
+		return c, fmt.Errorf("apply turn: %d: already applied", turn)
+	} else if turn != c.turn+1 {
+		return c, fmt.Errorf("apply turn: %d: expected turn %d", turn, c.turn+1)
+	}
+	return c.ApplyTurn(standardOfLiving), nil
+}
+
+// CanAbsorb returns whether the colony can take in qty more people
+// without going over capacity, along with an estimate of the extra deaths
+// each turn from the crowding after they arrive.
+//
+// The estimate compares each member's death rate at the percent capacity
+// after the intake with its rate in an uncrowded colony, at a standard of
+// living of 1.0. The newcomers are assumed to die at the average of those
+// rates, weighted by population, or at the tech 5 rate if the colony is
+// empty. Overcrowding only raises the death rate above 90% of capacity,
+// so a roomy destination reports no deaths. A colony without capacity
+// can't absorb anyone.
+func (c Colony) CanAbsorb(qty int) (ok bool, expectedDeaths int) {
+	const uncrowded = 0.5
+	if qty < 0 {
+		qty = 0
+	}
+	if c.capacity <= 0 {
+		return qty == 0, 0
+	}
+	pop := c.Population()
+	pctAfter := PctCapacity(pop+qty, c.capacity)
+	var excess, weight float64
+	for _, u := range c.members {
+		if pg, ok := u.(PopulationGroup); ok {
+			n := float64(pg.Population())
+			excess += n * (pg.NaturalDeathRate(1.0, pctAfter) - pg.NaturalDeathRate(1.0, uncrowded))
+			weight += n
+		}
+	}
+	rate := naturalDeathRate(referenceTechLevel, 1.0, pctAfter) - naturalDeathRate(referenceTechLevel, 1.0, uncrowded)
+	if weight > 0 {
+		rate = excess / weight
+	}
+	return pctAfter <= 1.0, int(float64(pop+qty) * rate)
+}
+
+// Capacity returns the number of people the colony can hold.
+func (c Colony) Capacity() int {
+	return c.capacity
+}
+
+// CapacityEffects returns the two ways crowding shows up in the colony at
+// the standard of living: the birth rate of its civilians and the fraction
+// of them that want to emigrate this turn. Both use PctCapacity, so they
+// always describe the same level of crowding.
+//
+// The birth rate is the average of the civilian members' natural birth
+// rates, weighted by population. Emigration starts when the colony passes
+// 90% of capacity, the point where overcrowding starts to raise the death
+// rate, and grows by 5% of the civilians for every 10% of capacity above
+// that, up to half of them.
+func (c Colony) CapacityEffects(standardOfLiving float64) (birthRate, emigration float64) {
+	const crowdedAt, ratePerTenPct = 0.90, 0.05
+	pctCapacity := c.PctCapacity()
+	civilians := 0
+	for _, u := range c.members {
+		if p, ok := u.(Civilian); ok {
+			birthRate += p.NaturalBirthRate(standardOfLiving, pctCapacity) * float64(p.Population())
+			civilians += p.Population()
+		}
+	}
+	if civilians == 0 {
+		return 0, 0
+	}
+	birthRate = birthRate / float64(civilians)
+	if pctCapacity > crowdedAt {
+		emigration = clamp((pctCapacity-crowdedAt)*10*ratePerTenPct, 0, 0.5)
+	}
+	return birthRate, emigration
+}
+
+// CapacityLimit returns the hard cap on the capacity of the colony.
+// Zero means there is no cap.
+func (c Colony) CapacityLimit() int {
+	return c.capacityLimit
+}
+
+// Checksum returns a 64-bit hash of the colony for verifying saves.
+// It combines the capacity with the code and fingerprint of every member.
+// The members are hashed in a canonical order, sorted by code and then by
+// fingerprint, so the order they were added in doesn't change the result.
+func (c Colony) Checksum() uint64 {
+	type member struct {
+		code        string
+		fingerprint uint64
+	}
+	members := make([]member, 0, len(c.members))
+	for _, u := range c.members {
+		members = append(members, member{code: u.Code(), fingerprint: unitFingerprint(u)})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].code != members[j].code {
+			return members[i].code < members[j].code
+		}
+		return members[i].fingerprint < members[j].fingerprint
+	})
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(int64(c.capacity)))
+	_, _ = h.Write(buf[:])
+	for _, m := range members {
+		_, _ = h.Write([]byte(m.code))
+		binary.LittleEndian.PutUint64(buf[:], m.fingerprint)
+		_, _ = h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// Clone returns a deep copy of the colony for speculative use.
+// The member slice is copied and each member is copied with its type's
+// Clone, so nothing done to the clone can leak into the original.
+// Members of other types are copied by value.
+func (c Colony) Clone() Colony {
+	members := make([]Unit, len(c.members))
+	for i, u := range c.members {
+		switch u := u.(type) {
+		case Civilian:
+			members[i] = u.Clone()
+		case Soldier:
+			members[i] = u.Clone()
+		default:
+			members[i] = u
+		}
+	}
+	c.members = members
+	return c
+}
+
+// Consolidate returns the colony with members that share the same unit code
+// and tech level merged into a single member. The merged member takes the
+// place of the first member in the group. Merging may turn a few loyal
+// citizens into rebels, but the total population is preserved.
+// Members that can't be merged are left alone.
+func (c Colony) Consolidate() Colony {
+	type key struct {
+		code string
+		tech int
+	}
+	index := map[key]int{}
+	var members []Unit
+	for _, u := range c.members {
+		k := key{code: u.Code(), tech: -1}
+		if tl, ok := u.(TechLevel); ok {
+			k.tech = tl.TechLevel()
+		}
+		if i, ok := index[k]; ok {
+			if merged, err := MergeUnits(members[i], u); err == nil {
+				members[i] = merged
+				continue
+			}
+		} else {
+			index[k] = len(members)
+		}
+		members = append(members, u)
+	}
+	c.members = members
+	return c
+}
+
+// Deliver returns the colony after a relocated unit arrives.
+//
+// When the colony has an immigration quota, only the people that fit in
+// what is left of this turn's quota are admitted; the unit is split with
+// SplitUnit and the rest are handled by the quota policy. A garrison
+// can't be split off, so it stays with the rest, and only the people
+// outside it are admitted when the unit doesn't fit. With BounceBack,
+// they are returned so the caller can send them back where they came from.
+// With TurnAway, they are refused entry, scatter to independent
+// settlements, and are lost; nil is returned. Units that aren't population
+// groups are always admitted.
+func (c Colony) Deliver(u Unit) (Colony, Unit, error) {
+	pg, ok := u.(PopulationGroup)
+	if !ok || c.immigration.quota <= 0 {
+		return c.AddUnit(u), nil, nil
+	}
+	room := c.immigration.quota - c.immigration.intake
+	if room < 0 {
+		room = 0
+	}
+	admitted, overflow := u, Unit(nil)
+	if pg.Population() > room {
+		if free := freeToLeave(pg); room > free {
+			room = free
+		}
+		var err error
+		if overflow, admitted, err = SplitUnit(u, room); err != nil {
+			return c, nil, fmt.Errorf("deliver: %w", err)
+		}
+	}
+	if apg, ok := admitted.(PopulationGroup); ok && apg.Population() > 0 {
+		c = c.AddUnit(admitted)
+		c.immigration.intake += apg.Population()
+	}
+	if c.immigration.policy == TurnAway {
+		return c, nil, nil
+	}
+	return c, overflow, nil
+}
+
+// ExpandCapacity returns the colony after construction crews add living space.
+//
+// Each unit of build output adds space for 100 people. When the colony has
+// a capacity limit, the gain is scaled by the fraction of the limit that is
+// not yet built, so returns diminish as the colony approaches the limit,
+// and capacity never exceeds it.
+func (c Colony) ExpandCapacity(buildOutput float64) Colony {
+	const peoplePerBuildUnit = 100
+	if buildOutput <= 0 {
+		return c
+	}
+	gain := buildOutput * peoplePerBuildUnit
+	if c.capacityLimit > 0 {
+		if c.capacity >= c.capacityLimit {
+			return c
+		}
+		gain *= float64(c.capacityLimit-c.capacity) / float64(c.capacityLimit)
+	}
+	c.capacity += int(gain)
+	if c.capacityLimit > 0 && c.capacity > c.capacityLimit {
+		c.capacity = c.capacityLimit
+	}
+	return c
+}
+
+// FilterByCode returns the members of the colony with the given code, in
+// the order they appear in the colony. It returns nil if no member has the
+// code. The colony is not changed.
+func (c Colony) FilterByCode(code string) []Unit {
+	var units []Unit
+	for _, u := range c.members {
+		if u.Code() == code {
+			units = append(units, u)
+		}
+	}
+	return units
+}
+
+// FoodBalance compares the FOOD produced this turn with the FOOD needed by the colony.
+// The surplus is produced minus needed. When the colony can't feed itself,
+// deficit is true and the surplus is negative; its magnitude is the shortfall.
+func (c Colony) FoodBalance(produced float64) (surplus float64, deficit bool) {
+	surplus = produced - c.FoodNeeded()
+	return surplus, surplus < 0
+}
+
+// FoodNeeded returns the FOOD units needed to sustain the members of the colony.
+func (c Colony) FoodNeeded() float64 {
+	var food float64
+	for _, u := range c.members {
+		if pg, ok := u.(PopulationGroup); ok {
+			food += pg.FoodNeeded()
+		}
+	}
+	return food
+}
+
+// FoodProduced returns the FOOD units produced in one turn by farm units.
+// Each farm unit yields 0.05 FOOD at tech 5, which feeds 400 people at
+// that tech level. The yield is scaled by tech level (0.50 at tech 0 and
+// 1.50 at tech 10) and by the ProductivityFactor of the colony.
+func (c Colony) FoodProduced(farmUnits float64, techLevel int) float64 {
+	if farmUnits <= 0 {
+		return 0
+	}
+	return farmUnits * 0.05 * techYieldFactor(techLevel) * c.ProductivityFactor()
+}
+
+// GoodsNeeded returns the consumer goods units the colony needs for a
+// standard of living of 1.0. Demand is 0.02 per 100 people.
+func (c Colony) GoodsNeeded() float64 {
+	return float64(c.Population()) * 0.01 * 0.02
+}
+
+// GoodsProduced returns the consumer goods units produced in one turn by factory units.
+// Each factory unit yields 0.05 goods at tech 5, which supplies 250 people.
+// The yield is scaled by tech level (0.50 at tech 0 and 1.50 at tech 10)
+// and by the ProductivityFactor of the colony.
+func (c Colony) GoodsProduced(factoryUnits float64, techLevel int) float64 {
+	if factoryUnits <= 0 {
+		return 0
+	}
+	return factoryUnits * 0.05 * techYieldFactor(techLevel) * c.ProductivityFactor()
+}
+
+// Headroom returns the number of colonists the planet can still support
+// on top of the population of the colony. It is never negative.
+func (c Colony) Headroom(pl Planet) int {
+	return pl.Headroom(c.Population())
+}
+
+// ImmigrationQuota returns the number of people the colony admits each
+// turn and what happens to the people over the quota.
+// A quota of zero means there is no limit.
+func (c Colony) ImmigrationQuota() (int, QuotaPolicy) {
+	return c.immigration.quota, c.immigration.policy
+}
+
+// IsFrozen returns true if the colony is frozen and skips simulation.
+func (c Colony) IsFrozen() bool {
+	return c.frozen
+}
+
+// LifeSupportNeeded returns the LS units needed to sustain the members of the colony.
+func (c Colony) LifeSupportNeeded() float64 {
+	var ls float64
+	for _, u := range c.members {
+		if pg, ok := u.(PopulationGroup); ok {
+			ls += pg.LifeSupportNeeded()
+		}
+	}
+	return ls
+}
+
+// LifeSupportProduced returns the LS units produced in one turn by life
+// support units. Each life support unit yields 1.0 LS at tech 5, which
+// sustains 200 people at that tech level. The yield is scaled by tech
+// level (0.50 at tech 0 and 1.50 at tech 10). A turn engine compares
+// the result with LifeSupportNeeded to find a shortage.
+func (c Colony) LifeSupportProduced(lsUnits float64, techLevel int) float64 {
+	if lsUnits <= 0 {
+		return 0
+	}
+	return lsUnits * 1.0 * techYieldFactor(techLevel)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+//
+// Members are written in a canonical order so that colonies with the same
+// members produce the same bytes no matter the order the members were
+// added: by unit code, then by tech level from highest to lowest, then by
+// population from largest to smallest. Members that tie on all three are
+// ordered by their json. Units without a tech level or population sort as
+// if they had -1. The order of the members in the colony is not changed.
+func (c Colony) MarshalJSON() ([]byte, error) {
+	type member struct {
+		aux       auxUnit
+		tech, pop int
+	}
+	members := make([]member, 0, len(c.members))
+	for _, u := range c.members {
+		data, err := json.Marshal(u)
+		if err != nil {
+			return nil, fmt.Errorf("encode colony: %s: %w", u.Code(), err)
+		}
+		m := member{aux: auxUnit{Code: u.Code(), Unit: data}, tech: -1, pop: -1}
+		if tl, ok := u.(TechLevel); ok {
+			m.tech = tl.TechLevel()
+		}
+		if pg, ok := u.(PopulationGroup); ok {
+			m.pop = pg.Population()
+		}
+		members = append(members, m)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		a, b := members[i], members[j]
+		if a.aux.Code != b.aux.Code {
+			return a.aux.Code < b.aux.Code
+		} else if a.tech != b.tech {
+			return a.tech > b.tech
+		} else if a.pop != b.pop {
+			return a.pop > b.pop
+		}
+		return bytes.Compare(a.aux.Unit, b.aux.Unit) < 0
+	})
+	aux := auxColony{
+		Capacity:      c.capacity,
+		CapacityLimit: c.capacityLimit,
+		Quota:         c.immigration.quota,
+		QuotaPolicy:   c.immigration.policy,
+		Frozen:        c.frozen,
+		Turn:          c.turn,
+		Standard:      c.standard,
+		Planet:        c.planet,
+		Members:       make([]auxUnit, 0, len(members)),
+	}
+	for _, m := range members {
+		aux.Members = append(aux.Members, m.aux)
+	}
+	return json.Marshal(&aux)
+}
+
+// Members returns a copy of the units in the colony.
+func (c Colony) Members() []Unit {
+	return append([]Unit(nil), c.members...)
+}
+
+// PctCapacity returns the population of the colony as a fraction of its capacity.
+func (c Colony) PctCapacity() float64 {
+	return PctCapacity(c.Population(), c.capacity)
+}
+
+// Planet returns the id of the planet the colony is on, or an empty
+// string if the colony hasn't been placed on one.
+func (c Colony) Planet() string {
+	return c.planet
+}
+
+// PerceivedStandard returns the standard of living as the members of the
+// colony perceive it, given the base standard from the supplies available.
+//
+// Each member sees the base standard scaled by the weight for its type,
+// and the result is the average of those, weighted by population.
+// Civilians and soldiers see the base standard (a weight of 1.0).
+// Professionals expect 25% more from the colony, so they see 80% of it.
+// A colony with no population reports the base standard.
+func (c Colony) PerceivedStandard(baseStandard float64) float64 {
+	var weighted, pop float64
+	for _, u := range c.members {
+		pg, ok := u.(PopulationGroup)
+		if !ok {
+			continue
+		}
+		weight := 1.0
+		if _, ok := u.(Professional); ok {
+			weight = 0.8
+		}
+		weighted += weight * float64(pg.Population())
+		pop += float64(pg.Population())
+	}
+	if pop == 0 {
+		return baseStandard
+	}
+	return baseStandard * weighted / pop
+}
+
+// PoliticalWeight returns the influence of the colony in the senate.
+// Each loyal person counts as 0.01 (so 100 people are one vote, matching
+// Quantity), scaled by the tech level of their unit the same way as
+// production: 0.50 at tech 0, 1.00 at tech 5, and 1.50 at tech 10.
+// Rebels have no weight at all. Members without a tech level count as
+// tech 5.
+func (c Colony) PoliticalWeight() float64 {
+	var weight float64
+	for _, u := range c.members {
+		pg, ok := u.(PopulationGroup)
+		if !ok {
+			continue
+		}
+		factor := 1.0
+		if tl, ok := u.(TechLevel); ok {
+			factor = techYieldFactor(tl.TechLevel())
+		}
+		weight += float64(pg.Population()-pg.Rebels()) * 0.01 * factor
+	}
+	return weight
+}
+
+// Population returns the total population of the members of the colony.
+func (c Colony) Population() int {
+	return int(c.Population64())
+}
+
+// WithCapacityLimit returns a copy of the colony with a hard cap on capacity.
+// Zero removes the cap.
+func (c Colony) WithCapacityLimit(limit int) Colony {
+	c.capacityLimit = limit
+	return c
+}
+
+// civilians returns the population of the civilian members of the colony.
+func (c Colony) civilians() int {
+	pop := 0
+	for _, u := range c.members {
+		if p, ok := u.(Civilian); ok {
+			pop += p.Population()
+		}
+	}
+	return pop
+}
+
+// Population64 returns the total population of the members of the colony
+// as an int64. The total is accumulated in 64 bits, so it doesn't wrap
+// around when the colony holds many large units.
+func (c Colony) Population64() int64 {
+	var pop int64
+	for _, u := range c.members {
+		if pg, ok := u.(PopulationGroup); ok {
+			pop += population64(pg)
+		}
+	}
+	return pop
+}
+
+// ProductivityFactor returns the fraction of normal output the colony
+// produces, using the Civilian.ProductivityFactor curve on the rebel
+// fraction of all of its civilian members. Colonies without civilians
+// return 1.0.
+func (c Colony) ProductivityFactor() float64 {
+	rebels := 0
+	for _, u := range c.members {
+		if p, ok := u.(Civilian); ok {
+			rebels += p.Rebels()
+		}
+	}
+	return productivityFactor(rebels, c.civilians())
+}
+
+// Rebels returns the number of rebels in the members of the colony.
+func (c Colony) Rebels() int {
+	rebels := 0
+	for _, u := range c.members {
+		if pg, ok := u.(PopulationGroup); ok {
+			rebels += pg.Rebels()
+		}
+	}
+	return rebels
+}
+
+// RemoveUnit returns a copy of the colony after qty people leave, along
+// with a unit holding the people who left.
+//
+// The people are taken from the first member with the code that has at
+// least qty people free to leave; the garrison of a civilian member stays
+// behind. A member that loses all of its people is removed and the order
+// of the other members is kept; otherwise the member is split with
+// SplitUnit. The capacity is not changed, and the original colony is not
+// modified. It returns an error if qty is not positive or no member is
+// large enough.
+func (c Colony) RemoveUnit(code string, qty int) (Colony, Unit, error) {
+	if qty <= 0 {
+		return c, nil, fmt.Errorf("remove unit: %s: %d: must be positive", code, qty)
+	}
+	for i, u := range c.members {
+		pg, ok := u.(PopulationGroup)
+		if !ok || u.Code() != code || freeToLeave(pg) < qty {
+			continue
+		}
+		members := make([]Unit, 0, len(c.members))
+		members = append(members, c.members[:i]...)
+		if pg.Population() == qty {
+			members = append(members, c.members[i+1:]...)
+			c.members = members
+			return c, u, nil
+		}
+		rest, part, err := SplitUnit(u, qty)
+		if err != nil {
+			return c, nil, fmt.Errorf("remove unit: %w", err)
+		}
+		members = append(append(members, rest), c.members[i+1:]...)
+		c.members = members
+		return c, part, nil
+	}
+	return c, nil, fmt.Errorf("remove unit: %s: no member with %d people free to leave", code, qty)
+}
+
+// RenderDashboard writes a few lines of ASCII bars showing the state of the
+// colony, meant to be redrawn each turn while watching a simulation in a
+// terminal. The bars show the rebels as a fraction of the population, the
+// standard of living used by the last turn on its 0 to 3 scale, and the
+// population as a fraction of capacity; bars are full at 100%. The output
+// depends only on the colony. Errors from the writer are ignored.
+func (c Colony) RenderDashboard(w io.Writer) {
+	const width = 40
+	pop, rebels := c.Population(), c.Rebels()
+	rebelFraction := 0.0
+	if pop > 0 {
+		rebelFraction = float64(rebels) / float64(pop)
+	}
+	pctCapacity := c.PctCapacity()
+	_, _ = fmt.Fprintf(w, "%-10s %d\n%-10s [%s] %5.1f%% %d\n%-10s [%s] %5.2f\n%-10s [%s] %5.1f%% of %d\n",
+		"Population", pop,
+		"Rebels", dashboardBar(rebelFraction, width), 100*rebelFraction, rebels,
+		"Standard", dashboardBar(c.standard/3.0, width), c.standard,
+		"Capacity", dashboardBar(pctCapacity, width), 100*pctCapacity, c.capacity)
+}
+
+// Report returns a fixed-width summary of the colony with one line per member,
+// the colony totals, and the FOOD and LS needed for the turn.
+// Members that aren't population groups show dashes for population and rebels.
+func (c Colony) Report() string {
+	sb := &strings.Builder{}
+	_, _ = fmt.Fprintf(sb, "%-5s %15s %15s %4s\n", "Code", "Population", "Rebels", "Tech")
+	for _, u := range c.members {
+		pop, rebels, tech := "-", "-", "-"
+		if pg, ok := u.(PopulationGroup); ok {
+			pop, rebels = fmt.Sprintf("%d", pg.Population()), fmt.Sprintf("%d", pg.Rebels())
+		}
+		if tl, ok := u.(TechLevel); ok {
+			tech = fmt.Sprintf("%d", tl.TechLevel())
+		}
+		_, _ = fmt.Fprintf(sb, "%-5s %15s %15s %4s\n", u.Code(), pop, rebels, tech)
+	}
+	_, _ = fmt.Fprintf(sb, "%-5s %15d %15d\n", "Total", c.Population(), c.Rebels())
+	_, _ = fmt.Fprintf(sb, "%-21s %15.4f\n", "Food needed", c.FoodNeeded())
+	_, _ = fmt.Fprintf(sb, "%-21s %15.4f\n", "Life support needed", c.LifeSupportNeeded())
+	return sb.String()
+}
+
+// RequiredFactories returns the factory units that must run at the given
+// tech level for GoodsProduced to meet GoodsNeeded, the goods needed for a
+// standard of living of 1.0. The count is fractional; round it up when
+// queuing builds. It returns 0 when nothing is needed and +Inf when the
+// colony is too rebellious to produce anything.
+func (c Colony) RequiredFactories(techLevel int) float64 {
+	return c.requiredUnits(c.GoodsNeeded(), techLevel)
+}
+
+// RequiredFarms returns the farm units that must run at the given tech
+// level for FoodProduced to meet FoodNeeded. As with RequiredFactories,
+// the count is fractional, and it is +Inf when nothing can be produced.
+func (c Colony) RequiredFarms(techLevel int) float64 {
+	return c.requiredUnits(c.FoodNeeded(), techLevel)
+}
+
+// StandardOfLiving returns the standard of living for the colony given the
+// FOOD and consumer goods available this turn.
+//
+// Each supply is converted to a ratio of available to needed, and the
+// ratios are blended by BlendStandard with food weighted at 75% and goods
+// at 25%. A fully fed colony with no goods has a standard of 0.75; adding
+// goods at the level of demand raises it to 1.00. The result is at least
+// 0.01, with no upper limit; the rate functions clamp it to the
+// StandardRange of their config.
+func (c Colony) StandardOfLiving(foodAvailable, goodsAvailable float64) float64 {
+	const foodWeight = 0.75
+	foodRatio, goodsRatio := 1.0, 1.0
+	if needed := c.FoodNeeded(); needed > 0 {
+		foodRatio = foodAvailable / needed
+	}
+	if needed := c.GoodsNeeded(); needed > 0 {
+		goodsRatio = goodsAvailable / needed
+	}
+	return BlendStandard(foodRatio, goodsRatio, foodWeight)
+}
+
+// Sustainable returns true if the FOOD and LS available cover the needs of
+// every member of the colony. Members in open colonies need no life support.
+func (c Colony) Sustainable(foodAvailable, lsAvailable float64) bool {
+	return foodAvailable >= c.FoodNeeded() && lsAvailable >= c.LifeSupportNeeded()
+}
+
+// Turn returns the sequence number of the last turn applied to the colony.
+// It is zero for a colony that hasn't had a turn.
+func (c Colony) Turn() int {
+	return c.turn
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// The code of each member selects the concrete type of the unit.
+func (c *Colony) UnmarshalJSON(data []byte) error {
+	var aux auxColony
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return fmt.Errorf("decode colony: %w", err)
+	}
+	members := make([]Unit, 0, len(aux.Members))
+	for i, m := range aux.Members {
+		u, err := unmarshalUnit(m.Code, m.Unit)
+		if err != nil {
+			return fmt.Errorf("decode colony: member %d: %w", i, err)
+		}
+		members = append(members, u)
+	}
+	c.capacity, c.capacityLimit, c.members, c.frozen = aux.Capacity, aux.CapacityLimit, members, aux.Frozen
+	c.turn, c.standard = aux.Turn, aux.Standard
+	c.planet = aux.Planet
+	c.immigration.quota, c.immigration.policy, c.immigration.intake = aux.Quota, aux.QuotaPolicy, 0
+	return nil
+}
+
+// WithFrozen returns a copy of the colony that is (or is not) frozen.
+// A frozen colony, for example one that is mothballed or under a stasis
+// field, neither grows nor dies. The members keep their own state, so
+// unfreezing the colony resumes the simulation where it stopped.
+func (c Colony) WithFrozen(frozen bool) Colony {
+	c.frozen = frozen
+	return c
+}
+
+// WithImmigrationQuota returns a copy of the colony that admits at most
+// quota people each turn, handling the rest with the policy.
+// A quota of zero removes the limit.
+func (c Colony) WithImmigrationQuota(quota int, policy QuotaPolicy) Colony {
+	c.immigration.quota, c.immigration.policy = quota, policy
+	return c
+}
+
+// WithPlanet returns a copy of the colony placed on the planet.
+// The colony records the planet's name, which is its id.
+func (c Colony) WithPlanet(pl Planet) Colony {
+	c.planet = pl.Name()
+	return c
+}
+
+// WithTurn returns a copy of the colony with the sequence number of the
+// last turn applied set to turn, for engines that start at a later turn.
+func (c Colony) WithTurn(turn int) Colony {
+	c.turn = turn
+	return c
+}
+
+// applyTurn implements ApplyTurn and ApplyTurnAt. Civilian members get
+// the grace period for currentTurn only when grace is set.
+func (c Colony) applyTurn(currentTurn int, grace bool, standardOfLiving float64) Colony {
+	c.turn++
+	if c.frozen {
+		return c
+	}
+	c.standard, c.immigration.intake = standardOfLiving, 0
+	pctCapacity := c.PctCapacity()
+	members := make([]Unit, len(c.members))
+	for i, u := range c.members {
+		if p, ok := u.(Civilian); ok {
+			if grace {
+				p = p.ApplyTurnAt(currentTurn, standardOfLiving, pctCapacity)
+			} else {
+				p = p.ApplyTurn(standardOfLiving, pctCapacity)
+			}
+			u = p.ApplyUnrest(standardOfLiving, 0)
+		} else if pg, ok := u.(PopulationGroup); ok {
+			u = killUnit(u, int(float64(pg.Population())*pg.NaturalDeathRate(standardOfLiving, pctCapacity)))
+		}
+		members[i] = u
+	}
+	c.members = members
+	return c
+}
+
+// fingerprint returns a 64-bit FNV-1a hash of the capacity and the state
+// of each member, in order, for the desync checks in Journal.Replay.
+// Each member hashes its code and then, if it implements
+// encoding.BinaryMarshaler, its packed form, which holds every field that
+// a turn can change, such as the residuals, radicalization, and factions
+// of civilians. Other members hash the fingerprint used by Checksum.
+func (c Colony) fingerprint() uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(int64(c.capacity)))
+	_, _ = h.Write(buf[:])
+	for _, u := range c.members {
+		_, _ = h.Write([]byte(u.Code()))
+		if bm, ok := u.(encoding.BinaryMarshaler); ok {
+			if data, err := bm.MarshalBinary(); err == nil {
+				_, _ = h.Write(data)
+				continue
+			}
+		}
+		binary.LittleEndian.PutUint64(buf[:], unitFingerprint(u))
+		_, _ = h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// immigrationRoom returns the number of people the colony can still admit
+// this turn, or -1 if there is no quota.
+func (c Colony) immigrationRoom() int {
+	if c.immigration.quota <= 0 {
+		return -1
+	} else if room := c.immigration.quota - c.immigration.intake; room > 0 {
+		return room
+	}
+	return 0
+}
+
+// migrants returns the number of civilians that are free to migrate,
+// which is everyone but the garrisons.
+func (c Colony) migrants() int {
+	pop := 0
+	for _, u := range c.members {
+		if p, ok := u.(Civilian); ok {
+			pop += p.Population() - p.garrison
+		}
+	}
+	return pop
+}
+
+// requiredUnits inverts FoodProduced and GoodsProduced, which share a
+// yield per unit, to find the units that produce the amount needed.
+func (c Colony) requiredUnits(needed float64, techLevel int) float64 {
+	if needed <= 0 {
+		return 0
+	}
+	yield := c.FoodProduced(1, techLevel)
+	if yield <= 0 {
+		return math.Inf(1)
+	}
+	return needed / yield
+}
+
+// ColonyKind is the type of colony a population lives in.
+type ColonyKind int
+
+const (
+	// OpenColony is a colony on a habitable planet. It is the default.
+	OpenColony ColonyKind = iota
+	// ClosedColony is a colony that depends on life support.
+	ClosedColony
+	// ResortColony is an open colony with a higher birth rate.
+	ResortColony
+)
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (k ColonyKind) MarshalText() ([]byte, error) {
+	switch k {
+	case OpenColony:
+		return []byte("open"), nil
+	case ClosedColony:
+		return []byte("closed"), nil
+	case ResortColony:
+		return []byte("resort"), nil
+	}
+	return nil, fmt.Errorf("invalid colony kind %d", int(k))
+}
+
+// String implements the fmt.Stringer interface.
+func (k ColonyKind) String() string {
+	if text, err := k.MarshalText(); err == nil {
+		return string(text)
+	}
+	return fmt.Sprintf("ColonyKind(%d)", int(k))
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (k *ColonyKind) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "open":
+		*k = OpenColony
+	case "closed":
+		*k = ClosedColony
+	case "resort":
+		*k = ResortColony
+	default:
+		return fmt.Errorf("invalid colony kind %q", string(text))
+	}
+	return nil
+}
+
+// Environment is the condition of the planet outside a closed colony.
+type Environment int
+
+const (
+	// Benign is a planet that does not attack the colony. It is the default.
+	Benign Environment = iota
+	// Hostile is a planet with extreme temperatures or pressures.
+	Hostile
+	// Toxic is a planet with a poisonous or corrosive atmosphere.
+	Toxic
+)
+
+// LifeSupportFactor returns the life support multiplier for a closed colony
+// in the environment: 1.0 for benign, 1.5 for hostile, and 2.0 for toxic
+// planets. Ships use 1.0 regardless of environment.
+func (e Environment) LifeSupportFactor() float64 {
+	switch e {
+	case Hostile:
+		return 1.5
+	case Toxic:
+		return 2.0
+	}
+	return 1.0
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (e Environment) MarshalText() ([]byte, error) {
+	switch e {
+	case Benign:
+		return []byte("benign"), nil
+	case Hostile:
+		return []byte("hostile"), nil
+	case Toxic:
+		return []byte("toxic"), nil
+	}
+	return nil, fmt.Errorf("invalid environment %d", int(e))
+}
+
+// String implements the fmt.Stringer interface.
+func (e Environment) String() string {
+	if text, err := e.MarshalText(); err == nil {
+		return string(text)
+	}
+	return fmt.Sprintf("Environment(%d)", int(e))
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (e *Environment) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "benign":
+		*e = Benign
+	case "hostile":
+		*e = Hostile
+	case "toxic":
+		*e = Toxic
+	default:
+		return fmt.Errorf("invalid environment %q", string(text))
+	}
+	return nil
+}
+
+// QuotaPolicy is what happens to people that arrive at a colony after it
+// has reached its immigration quota.
+type QuotaPolicy int
+
+const (
+	// BounceBack returns the people over the quota to the sender. It is the default.
+	BounceBack QuotaPolicy = iota
+	// TurnAway refuses the people over the quota, and they are lost.
+	TurnAway
+)
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (q QuotaPolicy) MarshalText() ([]byte, error) {
+	switch q {
+	case BounceBack:
+		return []byte("bounce-back"), nil
+	case TurnAway:
+		return []byte("turn-away"), nil
+	}
+	return nil, fmt.Errorf("invalid quota policy %d", int(q))
+}
+
+// String implements the fmt.Stringer interface.
+func (q QuotaPolicy) String() string {
+	if text, err := q.MarshalText(); err == nil {
+		return string(text)
+	}
+	return fmt.Sprintf("QuotaPolicy(%d)", int(q))
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (q *QuotaPolicy) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "bounce-back":
+		*q = BounceBack
+	case "turn-away":
+		*q = TurnAway
+	default:
+		return fmt.Errorf("invalid quota policy %q", string(text))
+	}
+	return nil
+}
@@ -0,0 +1,680 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestColonyFoodProduced(t *testing.T) {
+	c := wge.NewColony(10_000, wge.NewCivilian(5_000, 5))
+	for _, tc := range []struct {
+		id        int
+		farmUnits float64
+		techLevel int
+		expect    float64
+	}{
+		{1, 0, 5, 0},
+		{2, 1, 5, 0.05},
+		{3, 10, 5, 0.50},
+		{4, 10, 0, 0.25},
+		{5, 10, 10, 0.75},
+		{6, -1, 5, 0},
+	} {
+		if got := c.FoodProduced(tc.farmUnits, tc.techLevel); !isClose(tc.expect, got) {
+			t.Errorf("foodProduced: %d: expected %8.4f, got %8.4f\n", tc.id, tc.expect, got)
+		}
+	}
+	// output scales with both farm units and tech
+	if !(c.FoodProduced(20, 5) > c.FoodProduced(10, 5)) {
+		t.Errorf("foodProduced: expected more farms to produce more food\n")
+	}
+	if !(c.FoodProduced(10, 6) > c.FoodProduced(10, 5)) {
+		t.Errorf("foodProduced: expected higher tech to produce more food\n")
+	}
+}
+
+func TestColonyFoodBalance(t *testing.T) {
+	// 10,000 people at tech 5 need 1.25 FOOD
+	c := wge.NewColony(20_000, wge.NewCivilian(6_000, 5), wge.NewCivilian(4_000, 5))
+	if got := c.FoodNeeded(); !isClose(1.25, got) {
+		t.Errorf("foodNeeded: expected %8.4f, got %8.4f\n", 1.25, got)
+	}
+
+	// a colony with 30 farms feeds itself
+	surplus, deficit := c.FoodBalance(c.FoodProduced(30, 5))
+	if deficit || !isClose(0.25, surplus) {
+		t.Errorf("foodBalance: fed: expected 0.2500/false, got %8.4f/%v\n", surplus, deficit)
+	}
+
+	// a colony with 10 farms starves
+	surplus, deficit = c.FoodBalance(c.FoodProduced(10, 5))
+	if !deficit || !isClose(-0.75, surplus) {
+		t.Errorf("foodBalance: starving: expected -0.7500/true, got %8.4f/%v\n", surplus, deficit)
+	}
+}
+
+func TestColonyStandardOfLiving(t *testing.T) {
+	// 10,000 people at tech 5 need 1.25 FOOD and 2.0 goods
+	c := wge.NewColony(20_000, wge.NewCivilian(10_000, 5))
+	if got := c.GoodsNeeded(); !isClose(2.0, got) {
+		t.Errorf("goodsNeeded: expected %8.4f, got %8.4f\n", 2.0, got)
+	}
+	if got := c.GoodsProduced(40, 5); !isClose(2.0, got) {
+		t.Errorf("goodsProduced: expected %8.4f, got %8.4f\n", 2.0, got)
+	}
+	if !(c.GoodsProduced(40, 6) > c.GoodsProduced(40, 5)) {
+		t.Errorf("goodsProduced: expected higher tech to produce more goods\n")
+	}
+
+	// adding goods raises the standard of living with food held constant
+	for _, tc := range []struct {
+		id     int
+		food   float64
+		goods  float64
+		expect float64
+	}{
+		{1, 1.25, 0.0, 0.75},
+		{2, 1.25, 1.0, 0.875},
+		{3, 1.25, 2.0, 1.00},
+		{4, 1.25, 4.0, 1.25},
+		{5, 0.0, 0.0, 0.01},
+	} {
+		if got := c.StandardOfLiving(tc.food, tc.goods); !isClose(tc.expect, got) {
+			t.Errorf("standardOfLiving: %d: expected %8.4f, got %8.4f\n", tc.id, tc.expect, got)
+		}
+	}
+}
+
+func TestColonyReport(t *testing.T) {
+	var rebellious wge.Civilian
+	if err := json.Unmarshal([]byte(`{"loyal-citizens":1200,"rebel-citizens":34,"tech-level":10,"on-ship":true}`), &rebellious); err != nil {
+		t.Fatalf("report: expected nil, got %v\n", err)
+	}
+	c := wge.NewColony(20_000, wge.NewCivilian(12_345_678, 2), rebellious)
+	expect := `Code       Population          Rebels Tech
+CIV          12345678               0    2
+CIV              1234              34   10
+Total        12346912              34
+Food needed                 1774.8069
+Life support needed            3.7020
+`
+	if got := c.Report(); got != expect {
+		t.Errorf("report: expected\n%s\ngot\n%s\n", expect, got)
+	}
+}
+
+func TestColonyExpandCapacity(t *testing.T) {
+	// without a limit, each unit of build output adds 100 people
+	c := wge.NewColony(10_000).ExpandCapacity(5)
+	if got := c.Capacity(); got != 10_500 {
+		t.Errorf("expand: unlimited: expected %d, got %d\n", 10_500, got)
+	}
+
+	// with a limit, repeated expansion approaches but never exceeds it
+	c = wge.NewColony(10_000).WithCapacityLimit(20_000)
+	prior := c.Capacity()
+	for turn := 1; turn <= 500; turn++ {
+		c = c.ExpandCapacity(50)
+		if c.Capacity() > 20_000 {
+			t.Fatalf("expand: turn %d: expected <= %d, got %d\n", turn, 20_000, c.Capacity())
+		} else if c.Capacity() < prior {
+			t.Fatalf("expand: turn %d: expected >= %d, got %d\n", turn, prior, c.Capacity())
+		}
+		prior = c.Capacity()
+	}
+	if !(c.Capacity() > 19_900) {
+		t.Errorf("expand: expected capacity to approach %d, got %d\n", 20_000, c.Capacity())
+	}
+	// the first expansion gains more than a later one
+	first := wge.NewColony(10_000).WithCapacityLimit(20_000).ExpandCapacity(10).Capacity() - 10_000
+	later := wge.NewColony(18_000).WithCapacityLimit(20_000).ExpandCapacity(10).Capacity() - 18_000
+	if !(later < first) {
+		t.Errorf("expand: expected diminishing returns, got %d then %d\n", first, later)
+	}
+}
+
+func TestColonySustainable(t *testing.T) {
+	// an open colony needs food but no life support
+	open := wge.NewColony(20_000, wge.NewCivilian(10_000, 5))
+	if !open.Sustainable(1.25, 0) {
+		t.Errorf("sustainable: open: expected true, got false\n")
+	}
+	if open.Sustainable(1.0, 0) {
+		t.Errorf("sustainable: open: starving: expected false, got true\n")
+	}
+
+	// a ship-based member needs life support even when food is plentiful
+	mixed := wge.NewColony(20_000, wge.NewCivilian(10_000, 5), wge.NewCivilian(1_000, 5).WithShip(true))
+	if got := mixed.LifeSupportNeeded(); !isClose(5, got) {
+		t.Errorf("sustainable: mixed: expected life support %8.4f, got %8.4f\n", 5.0, got)
+	}
+	if mixed.Sustainable(100, 4.9) {
+		t.Errorf("sustainable: mixed: expected false, got true\n")
+	}
+	if !mixed.Sustainable(100, 5) {
+		t.Errorf("sustainable: mixed: supported: expected true, got false\n")
+	}
+}
+
+func TestColonyConsolidate(t *testing.T) {
+	c := wge.NewColony(100_000,
+		wge.NewCivilian(1_000, 3),
+		wge.NewCivilian(2_000, 5),
+		wge.NewCivilian(3_000, 3),
+		wge.NewSoldier(500, 3),
+		wge.NewCivilian(4_000, 3),
+	).Consolidate()
+	members := c.Members()
+	if len(members) != 3 {
+		t.Fatalf("consolidate: members: expected 3, got %d\n", len(members))
+	}
+	if p, ok := members[0].(wge.Civilian); !ok || p.TechLevel() != 3 || p.Population() != 8_000 {
+		t.Errorf("consolidate: tech 3: expected 8000 civilians, got %+v\n", members[0])
+	}
+	if p, ok := members[1].(wge.Civilian); !ok || p.TechLevel() != 5 || p.Population() != 2_000 || p.Rebels() != 0 {
+		t.Errorf("consolidate: tech 5: expected untouched 2000 civilians, got %+v\n", members[1])
+	}
+	if s, ok := members[2].(wge.Soldier); !ok || s.Population() != 500 {
+		t.Errorf("consolidate: soldiers: expected 500 soldiers, got %+v\n", members[2])
+	}
+	if c.Population() != 10_500 {
+		t.Errorf("consolidate: population: expected 10500, got %d\n", c.Population())
+	}
+}
+
+func TestColonyCapacityEffects(t *testing.T) {
+	empty := wge.NewColony(100_000, wge.NewCivilian(5_000, 5))
+	full := wge.NewColony(100_000, wge.NewCivilian(99_000, 5))
+	crowded := wge.NewColony(100_000, wge.NewCivilian(120_000, 5))
+
+	emptyBirths, emptyEmigration := empty.CapacityEffects(1.0)
+	fullBirths, fullEmigration := full.CapacityEffects(1.0)
+	crowdedBirths, crowdedEmigration := crowded.CapacityEffects(1.0)
+	if emptyEmigration != 0 {
+		t.Errorf("capacityEffects: empty: expected no emigration, got %g\n", emptyEmigration)
+	}
+	if !(emptyBirths > fullBirths) {
+		t.Errorf("capacityEffects: expected empty births %g > full births %g\n", emptyBirths, fullBirths)
+	}
+	if !(fullEmigration > 0 && crowdedEmigration > fullEmigration) {
+		t.Errorf("capacityEffects: expected 0 < full %g < crowded %g\n", fullEmigration, crowdedEmigration)
+	}
+	if !(crowdedBirths <= fullBirths) {
+		t.Errorf("capacityEffects: expected crowded births %g <= full births %g\n", crowdedBirths, fullBirths)
+	}
+	// the birth rate is the one the civilians will use this turn
+	p := wge.NewCivilian(99_000, 5)
+	if expect := p.NaturalBirthRate(1.0, full.PctCapacity()); fullBirths != expect {
+		t.Errorf("capacityEffects: full: expected birth rate %g, got %g\n", expect, fullBirths)
+	}
+	if births, emigration := wge.NewColony(1_000, wge.NewSoldier(900, 5)).CapacityEffects(1.0); births != 0 || emigration != 0 {
+		t.Errorf("capacityEffects: soldiers: expected 0 0, got %g %g\n", births, emigration)
+	}
+}
+
+func TestColonyAddRemoveUnit(t *testing.T) {
+	p, _ := wge.NewCivilianBuilder().Loyal(9_000).Rebel(1_000).Tech(5).Build()
+	c := wge.NewColony(50_000, p)
+	withSoldiers := c.AddUnit(wge.NewSoldier(500, 5))
+	if got := len(c.Members()); got != 1 {
+		t.Errorf("addUnit: original: expected 1 member, got %d\n", got)
+	}
+	members := withSoldiers.Members()
+	if len(members) != 2 || members[1].Code() != "SLD" {
+		t.Fatalf("addUnit: expected soldiers after civilians, got %+v\n", members)
+	}
+	if withSoldiers.Population() != 10_500 || withSoldiers.Capacity() != 50_000 {
+		t.Errorf("addUnit: expected 10500 people in 50000, got %d in %d\n", withSoldiers.Population(), withSoldiers.Capacity())
+	}
+
+	half, removed, err := withSoldiers.RemoveUnit("CIV", 5_000)
+	if err != nil {
+		t.Fatalf("removeUnit: expected nil, got %v\n", err)
+	}
+	if pg, ok := removed.(wge.PopulationGroup); !ok || pg.Population() != 5_000 || pg.Rebels() != 500 {
+		t.Errorf("removeUnit: removed: expected 5000 with 500 rebels, got %+v\n", removed)
+	}
+	members = half.Members()
+	if len(members) != 2 || members[0].Code() != "CIV" || members[1].Code() != "SLD" {
+		t.Fatalf("removeUnit: expected civilians then soldiers, got %+v\n", members)
+	}
+	if half.Population() != 5_500 || half.Rebels() != 500 {
+		t.Errorf("removeUnit: expected 5500 with 500 rebels, got %d with %d\n", half.Population(), half.Rebels())
+	}
+	if withSoldiers.Population() != 10_500 {
+		t.Errorf("removeUnit: original: expected 10500, got %d\n", withSoldiers.Population())
+	}
+
+	// removing a whole unit drops it from the colony
+	if noSoldiers, _, err := half.RemoveUnit("SLD", 500); err != nil {
+		t.Errorf("removeUnit: soldiers: expected nil, got %v\n", err)
+	} else if got := len(noSoldiers.Members()); got != 1 {
+		t.Errorf("removeUnit: soldiers: expected 1 member, got %d\n", got)
+	}
+	for _, tc := range []struct {
+		id   int
+		code string
+		qty  int
+	}{
+		{1, "CIV", 0},
+		{2, "CIV", 6_000},
+		{3, "XXX", 1},
+	} {
+		if _, _, err := half.RemoveUnit(tc.code, tc.qty); err == nil {
+			t.Errorf("removeUnit: %d: expected error, got nil\n", tc.id)
+		}
+	}
+}
+
+func TestColonyRemoveUnitGarrison(t *testing.T) {
+	// the first member has only 50 people outside its garrison
+	pinned, _ := wge.NewCivilian(500, 5).WithGarrison(450)
+	c := wge.NewColony(10_000, pinned, wge.NewCivilian(1_000, 5))
+	got, part, err := c.RemoveUnit("CIV", 200)
+	if err != nil {
+		t.Fatalf("removeUnit: garrison: expected nil, got %v\n", err)
+	} else if part.(wge.Civilian).Population() != 200 {
+		t.Errorf("removeUnit: garrison: expected 200 removed, got %+v\n", part)
+	}
+	members := got.Members()
+	if members[0] != wge.Unit(pinned) || members[1].(wge.Civilian).Population() != 800 {
+		t.Errorf("removeUnit: garrison: expected the second member to shrink, got %+v\n", members)
+	}
+	if _, _, err := wge.NewColony(10_000, pinned).RemoveUnit("CIV", 100); err == nil {
+		t.Errorf("removeUnit: garrison: too many: expected error, got nil\n")
+	}
+}
+
+func TestColonyMarshalJSON(t *testing.T) {
+	rebels, _ := wge.NewCivilianBuilder().Loyal(900).Rebel(100).Tech(5).Build()
+	members := []wge.Unit{
+		wge.NewCivilian(1_000, 3),
+		wge.NewSoldier(500, 5),
+		wge.NewCivilian(1_000, 5),
+		rebels,
+		wge.NewCivilian(2_000, 5),
+		wge.NewSoldier(800, 2),
+	}
+	expect, err := json.Marshal(wge.NewColony(10_000, members...))
+	if err != nil {
+		t.Fatalf("marshalJSON: expected nil, got %v\n", err)
+	}
+	// every rotation and the reversal of the members produce the same json
+	for i := range members {
+		shuffled := append(append([]wge.Unit(nil), members[i:]...), members[:i]...)
+		if i == len(members)-1 {
+			for a, b := 0, len(shuffled)-1; a < b; a, b = a+1, b-1 {
+				shuffled[a], shuffled[b] = shuffled[b], shuffled[a]
+			}
+		}
+		got, err := json.Marshal(wge.NewColony(10_000, shuffled...))
+		if err != nil {
+			t.Fatalf("marshalJSON: %d: expected nil, got %v\n", i, err)
+		} else if string(got) != string(expect) {
+			t.Errorf("marshalJSON: %d: expected %s, got %s\n", i, expect, got)
+		}
+	}
+
+	var c wge.Colony
+	if err := json.Unmarshal(expect, &c); err != nil {
+		t.Fatalf("unmarshalJSON: expected nil, got %v\n", err)
+	}
+	var order []string
+	for _, u := range c.Members() {
+		order = append(order, fmt.Sprintf("%s/%d/%d", u.Code(), u.(wge.TechLevel).TechLevel(), u.(wge.PopulationGroup).Population()))
+	}
+	if got := strings.Join(order, " "); got != "CIV/5/2000 CIV/5/1000 CIV/5/1000 CIV/3/1000 SLD/5/500 SLD/2/800" {
+		t.Errorf("unmarshalJSON: order: got %s\n", got)
+	}
+	if c.Capacity() != 10_000 || c.Population() != 6_300 || c.Rebels() != 100 {
+		t.Errorf("unmarshalJSON: expected 6300 with 100 rebels in 10000, got %d with %d in %d\n", c.Population(), c.Rebels(), c.Capacity())
+	}
+}
+
+func TestColonyLifeSupportProduced(t *testing.T) {
+	p := wge.NewCivilian(2_000, 5).WithColonyKind(wge.ClosedColony)
+	c := wge.NewColony(10_000, p)
+	for _, tc := range []struct {
+		id        int
+		lsUnits   float64
+		techLevel int
+		expect    float64
+	}{
+		{1, 0, 5, 0},
+		{2, 1, 5, 1.0},
+		{3, 10, 5, 10.0},
+		{4, 10, 0, 5.0},
+		{5, 10, 10, 15.0},
+		{6, 20, 10, 30.0},
+		{7, -1, 5, 0},
+	} {
+		if got := c.LifeSupportProduced(tc.lsUnits, tc.techLevel); !isClose(got, tc.expect) {
+			t.Errorf("lifeSupportProduced: %d: expected %g, got %g\n", tc.id, tc.expect, got)
+		}
+	}
+	// ten units at tech 5 sustain 2,000 people
+	if got, need := c.LifeSupportProduced(10, 5), c.LifeSupportNeeded(); !isClose(got, need) {
+		t.Errorf("lifeSupportProduced: expected %g, got %g\n", need, got)
+	}
+}
+
+func TestColonyPerceivedStandard(t *testing.T) {
+	for _, tc := range []struct {
+		id     int
+		c      wge.Colony
+		base   float64
+		expect float64
+	}{
+		{1, wge.NewColony(10_000, wge.NewCivilian(5_000, 5)), 1.0, 1.0},
+		{2, wge.NewColony(10_000, wge.NewCivilian(5_000, 5), wge.NewSoldier(1_000, 5)), 1.2, 1.2},
+		{3, wge.NewColony(10_000, wge.NewProfessional(5_000, 5)), 1.0, 0.8},
+		{4, wge.NewColony(10_000, wge.NewCivilian(1_000, 5), wge.NewProfessional(3_000, 5)), 1.0, 0.85},
+		{5, wge.NewColony(10_000), 1.5, 1.5},
+	} {
+		if got := tc.c.PerceivedStandard(tc.base); !isClose(got, tc.expect) {
+			t.Errorf("perceivedStandard: %d: expected %g, got %g\n", tc.id, tc.expect, got)
+		}
+	}
+}
+
+func TestColonyClone(t *testing.T) {
+	c := wge.NewColony(20_000, wge.NewCivilian(10_000, 5), wge.NewSoldier(500, 5), wge.NewProfessional(200, 6))
+	report := c.Report()
+
+	clone := c.Clone()
+	if clone.Report() != report {
+		t.Errorf("clone: expected\n%s\ngot\n%s\n", report, clone.Report())
+	}
+	// speculate on the clone
+	clone = clone.ApplyTurn(1.5)
+	clone, _, _ = clone.RemoveUnit("SLD", 250)
+	clone = clone.AddUnit(wge.NewCivilian(1_000, 2))
+	members := clone.Members()
+	members[0] = wge.NewCivilian(1, 1)
+	if clone.Population() == c.Population() {
+		t.Errorf("clone: expected the clone to change, got %d\n", clone.Population())
+	}
+	if got := c.Report(); got != report {
+		t.Errorf("clone: original: expected\n%s\ngot\n%s\n", report, got)
+	}
+	// overwriting a clone in place leaves the original alone
+	other := c.Clone()
+	if err := json.Unmarshal([]byte(`{"capacity":10,"members":[{"code":"CIV","unit":{"loyal-citizens":1,"rebel-citizens":0,"tech-level":1}}]}`), &other); err != nil {
+		t.Fatalf("clone: unmarshal: expected nil, got %v\n", err)
+	}
+	if other.Population() != 1 {
+		t.Errorf("clone: unmarshal: expected 1, got %d\n", other.Population())
+	}
+	if got := c.Report(); got != report {
+		t.Errorf("clone: unmarshal: original: expected\n%s\ngot\n%s\n", report, got)
+	}
+}
+
+func TestColonyFrozen(t *testing.T) {
+	c := wge.NewColony(20_000, wge.NewCivilian(10_000, 5), wge.NewSoldier(500, 5))
+	frozen := c.WithFrozen(true)
+	for turn := 0; turn < 100; turn++ {
+		frozen = frozen.ApplyTurn(1.0)
+	}
+	if got, expect := frozen.Population(), c.Population(); got != expect {
+		t.Errorf("frozen: expected population %d, got %d\n", expect, got)
+	}
+	// the frozen state is saved with the colony
+	data, err := json.Marshal(frozen)
+	if err != nil {
+		t.Fatalf("frozen: marshal: expected nil, got %v\n", err)
+	}
+	var got wge.Colony
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("frozen: unmarshal: expected nil, got %v\n", err)
+	} else if !got.IsFrozen() {
+		t.Errorf("frozen: unmarshal: expected frozen, got %s\n", data)
+	}
+	// unfreezing picks up where the colony left off
+	thawed, expect := got.WithFrozen(false).ApplyTurn(1.0), c.ApplyTurn(1.0)
+	if thawed.Population() == c.Population() {
+		t.Errorf("frozen: thawed: expected population to change from %d\n", c.Population())
+	} else if thawed.Population() != expect.Population() {
+		t.Errorf("frozen: thawed: expected population %d, got %d\n", expect.Population(), thawed.Population())
+	}
+}
+
+func TestColonyPoliticalWeight(t *testing.T) {
+	c := wge.NewColony(50_000, wge.NewCivilian(10_000, 5), wge.NewCivilian(1_000, 10), wge.NewSoldier(500, 0))
+	// 100 + 10*1.5 + 5*0.5
+	if got := c.PoliticalWeight(); !isClose(117.5, got) {
+		t.Errorf("politicalWeight: expected 117.5, got %g\n", got)
+	}
+	// converting loyal citizens to rebels costs their weight
+	restless, _ := wge.NewCivilianBuilder().Loyal(8_000).Rebel(2_000).Tech(5).Build()
+	r := wge.NewColony(50_000, restless, wge.NewCivilian(1_000, 10), wge.NewSoldier(500, 0))
+	if got := r.PoliticalWeight(); !isClose(97.5, got) {
+		t.Errorf("politicalWeight: rebels: expected 97.5, got %g\n", got)
+	}
+	if got := wge.NewColony(1_000).PoliticalWeight(); got != 0 {
+		t.Errorf("politicalWeight: empty: expected 0, got %g\n", got)
+	}
+}
+
+func TestColonyCanAbsorb(t *testing.T) {
+	for _, tc := range []struct {
+		id       int
+		c        wge.Colony
+		qty      int
+		ok       bool
+		hasDeath bool
+	}{
+		{1, wge.NewColony(20_000, wge.NewCivilian(5_000, 5)), 5_000, true, false},
+		{2, wge.NewColony(10_000, wge.NewCivilian(9_000, 5)), 5_000, false, true},
+		{3, wge.NewColony(10_000, wge.NewCivilian(9_000, 5), wge.NewSoldier(500, 7)), 3_000, false, true},
+		{4, wge.NewColony(10_000), 2_000, true, false},
+		{5, wge.NewColony(10_000), 12_000, false, true},
+		{6, wge.NewColony(0), 1, false, false},
+	} {
+		ok, deaths := tc.c.CanAbsorb(tc.qty)
+		if ok != tc.ok {
+			t.Errorf("canAbsorb: %d: ok: expected %v, got %v\n", tc.id, tc.ok, ok)
+		}
+		if tc.hasDeath != (deaths > 0) {
+			t.Errorf("canAbsorb: %d: deaths: expected positive %v, got %d\n", tc.id, tc.hasDeath, deaths)
+		}
+	}
+}
+
+func TestColonyApplyTurnOnce(t *testing.T) {
+	c := wge.NewColony(20_000, wge.NewCivilian(10_000, 5))
+	once, err := c.ApplyTurnOnce(1, 1.0)
+	if err != nil {
+		t.Fatalf("applyTurnOnce: 1: expected nil, got %v\n", err)
+	} else if once.Turn() != 1 {
+		t.Errorf("applyTurnOnce: 1: expected turn 1, got %d\n", once.Turn())
+	}
+	// applying the same turn again changes nothing
+	twice, err := once.ApplyTurnOnce(1, 1.0)
+	if err == nil {
+		t.Errorf("applyTurnOnce: repeat: expected error, got nil\n")
+	}
+	if twice.Population() != once.Population() || twice.Turn() != 1 {
+		t.Errorf("applyTurnOnce: repeat: expected %d at turn 1, got %d at turn %d\n", once.Population(), twice.Population(), twice.Turn())
+	}
+	// neither does skipping ahead
+	if _, err := once.ApplyTurnOnce(3, 1.0); err == nil {
+		t.Errorf("applyTurnOnce: skip: expected error, got nil\n")
+	}
+	// the sequence is saved with the colony
+	data, err := json.Marshal(once)
+	if err != nil {
+		t.Fatalf("applyTurnOnce: marshal: expected nil, got %v\n", err)
+	}
+	var got wge.Colony
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("applyTurnOnce: unmarshal: expected nil, got %v\n", err)
+	} else if got.Turn() != 1 {
+		t.Errorf("applyTurnOnce: json: expected turn 1, got %d\n", got.Turn())
+	} else if _, err := got.ApplyTurnOnce(1, 1.0); err == nil {
+		t.Errorf("applyTurnOnce: json: repeat: expected error, got nil\n")
+	} else if next, err := got.ApplyTurnOnce(2, 1.0); err != nil || next.Turn() != 2 {
+		t.Errorf("applyTurnOnce: json: 2: expected turn 2, got %d %v\n", next.Turn(), err)
+	}
+}
+
+func TestColonyUnrest(t *testing.T) {
+	// a restive colony hardens its rebels every turn
+	restive, _ := wge.NewCivilianBuilder().Loyal(2_000).Rebel(8_000).Tech(5).Build()
+	c := wge.NewColony(100_000, restive)
+	prev := 4_000
+	for turn := 1; turn <= 3; turn++ {
+		c = c.ApplyTurn(1.0)
+		p := c.Members()[0].(wge.Civilian)
+		if expect, got := 0.1*float64(turn), p.Radicalization(); !isClose(expect, got) {
+			t.Errorf("unrest: restive: turn %d: expected %g, got %g\n", turn, expect, got)
+		}
+		_, got := p.Suppress(0.5)
+		if !(got < prev) {
+			t.Errorf("unrest: restive: turn %d: expected fewer than %d pacified, got %d\n", turn, prev, got)
+		}
+		prev = got
+	}
+	// in a content colony, rebels drift back to loyalty during the turn
+	content, _ := wge.NewCivilianBuilder().Loyal(9_000).Rebel(1_000).Tech(5).Build()
+	c = wge.NewColony(100_000, content).ApplyTurn(1.0)
+	if got := c.Rebels(); got != 941 {
+		t.Errorf("unrest: content: expected 941 rebels, got %d\n", got)
+	} else if got := c.Population(); got != 10_900 {
+		t.Errorf("unrest: content: expected 10900 people, got %d\n", got)
+	}
+}
+
+func TestColonyGrace(t *testing.T) {
+	// settlers founded on game turn 1, dumped at 160% capacity
+	settlers := wge.NewCivilian(16_000, 5).WithFoundedTurn(1)
+	legacy := wge.NewCivilian(16_000, 5)
+	fresh, established := wge.NewColony(10_000, settlers).ApplyTurnAt(1, 1.0), wge.NewColony(10_000, legacy).ApplyTurnAt(1, 1.0)
+	if !(fresh.Population() > established.Population()) {
+		t.Errorf("grace: colony: expected %d > %d\n", fresh.Population(), established.Population())
+	}
+	// without the game turn there is no grace
+	if got := wge.NewColony(10_000, settlers).ApplyTurn(1.0).Population(); got != established.Population() {
+		t.Errorf("grace: applyTurn: expected %d, got %d\n", established.Population(), got)
+	}
+	// a unit founded on a later game turn gets no grace before then, even
+	// though the colony's own turn counter starts at 0
+	late, old := wge.NewColony(1_000, wge.NewCivilian(3_000, 5).WithFoundedTurn(50)), wge.NewColony(1_000, wge.NewCivilian(3_000, 5))
+	for turn := 1; turn <= 20; turn++ {
+		late, old = late.ApplyTurnAt(turn, 1.0), old.ApplyTurnAt(turn, 1.0)
+	}
+	if late.Population() != old.Population() {
+		t.Errorf("grace: future: expected %d, got %d\n", old.Population(), late.Population())
+	}
+	// the engine runs the same turn
+	e := wge.NewEngine(wge.NewSystem(wge.NewColony(10_000, settlers), wge.NewColony(10_000, legacy)), 7)
+	got := e.Step(wge.TurnInput{StandardOfLiving: 1.0}).Entries
+	if got[0].Population != fresh.Population() || got[1].Population != established.Population() {
+		t.Errorf("grace: engine: expected %d and %d, got %d and %d\n", fresh.Population(), established.Population(), got[0].Population, got[1].Population)
+	}
+}
+
+func TestColonySoldierDeaths(t *testing.T) {
+	// soldiers don't reproduce, but they die like everyone else
+	c := wge.NewColony(1_000, wge.NewSoldier(5_000, 5))
+	prev := 5_000
+	for turn := 1; turn <= 10; turn++ {
+		c = c.ApplyTurn(1.0)
+		if got := c.Population(); !(got < prev) {
+			t.Errorf("soldiers: turn %d: expected fewer than %d, got %d\n", turn, prev, got)
+		}
+		prev = c.Population()
+	}
+	if got := wge.NewColony(100_000, wge.NewSoldier(5_000, 5)).ApplyTurn(1.0).Population(); got != 4_950 {
+		t.Errorf("soldiers: uncrowded: expected 4950, got %d\n", got)
+	}
+}
+
+func TestColonyRenderDashboard(t *testing.T) {
+	rebels, _ := wge.NewCivilianBuilder().Loyal(8_000).Rebel(2_000).Tech(5).Build()
+	c := wge.NewColony(20_000, rebels).ApplyTurn(1.5)
+	expect := "Population 10655\n" +
+		"Rebels     [#######.................................]  17.7% 1882\n" +
+		"Standard   [####################....................]  1.50\n" +
+		"Capacity   [#####################...................]  53.3% of 20000\n"
+	var first, second strings.Builder
+	c.RenderDashboard(&first)
+	if got := first.String(); got != expect {
+		t.Errorf("renderDashboard: expected\n%s\ngot\n%s\n", expect, got)
+	}
+	c.RenderDashboard(&second)
+	if second.String() != first.String() {
+		t.Errorf("renderDashboard: expected the same output twice, got\n%s\n", second.String())
+	}
+}
+
+func TestColonyFilterByCode(t *testing.T) {
+	c := wge.NewColony(100_000, wge.NewCivilian(1_000, 5), wge.NewSoldier(200, 5), wge.NewCivilian(500, 3))
+	civs := c.FilterByCode("CIV")
+	if len(civs) != 2 {
+		t.Fatalf("filterByCode: civ: expected 2 units, got %d\n", len(civs))
+	}
+	for i, expect := range []int{1_000, 500} {
+		if got := civs[i].(wge.Civilian).Population(); got != expect {
+			t.Errorf("filterByCode: civ: %d: expected %d, got %d\n", i, expect, got)
+		}
+	}
+	if got := len(c.FilterByCode("SLD")); got != 1 {
+		t.Errorf("filterByCode: sld: expected 1 unit, got %d\n", got)
+	}
+	if got := c.FilterByCode("XYZ"); len(got) != 0 {
+		t.Errorf("filterByCode: absent: expected no units, got %d\n", len(got))
+	}
+	// the colony keeps its members
+	if got := len(c.Members()); got != 3 {
+		t.Errorf("filterByCode: members: expected 3, got %d\n", got)
+	}
+}
+
+func TestColonyRequiredFarms(t *testing.T) {
+	for _, tc := range []struct {
+		id   int
+		c    wge.Colony
+		tech int
+	}{
+		{1, wge.NewColony(100_000, wge.NewCivilian(40_000, 5)), 5},
+		{2, wge.NewColony(100_000, wge.NewCivilian(12_345, 2), wge.NewSoldier(678, 2)), 2},
+		{3, wge.NewColony(100_000, wge.CivilianFromHeadcount(50_000, 10_000, 8)), 8},
+	} {
+		farms := tc.c.RequiredFarms(tc.tech)
+		if food, needed := tc.c.FoodProduced(farms, tc.tech), tc.c.FoodNeeded(); !isClose(needed, food) {
+			t.Errorf("requiredFarms: %d: expected %f food, got %f\n", tc.id, needed, food)
+		}
+		factories := tc.c.RequiredFactories(tc.tech)
+		if goods, needed := tc.c.GoodsProduced(factories, tc.tech), tc.c.GoodsNeeded(); !isClose(needed, goods) {
+			t.Errorf("requiredFactories: %d: expected %f goods, got %f\n", tc.id, needed, goods)
+		}
+	}
+	// each farm feeds 400 people at tech 5
+	if got := wge.NewColony(100_000, wge.NewCivilian(40_000, 5)).RequiredFarms(5); !isClose(100, got) {
+		t.Errorf("requiredFarms: tech 5: expected 100, got %f\n", got)
+	}
+	if got := wge.NewColony(100_000).RequiredFarms(5); got != 0 {
+		t.Errorf("requiredFarms: empty: expected 0, got %f\n", got)
+	}
+}
@@ -0,0 +1,74 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge
+
+// Disaster is a random event, such as a meteor strike or a reactor
+// failure, that can strike a colony during a turn.
+type Disaster struct {
+	Name string
+	// Probability is the chance the disaster strikes in a turn, from 0 to 1.
+	Probability float64
+	// Casualties is the fraction of the people in each member that are killed.
+	Casualties float64
+	// CapacityLoss is the fraction of the colony's capacity that is destroyed.
+	CapacityLoss float64
+}
+
+// DisasterTable is the list of disasters that can strike a colony.
+// The disasters are rolled in order.
+type DisasterTable []Disaster
+
+// Event is a record of a disaster that struck a colony.
+type Event struct {
+	Name         string
+	Deaths       int
+	CapacityLost int
+}
+
+// ApplyDisasters returns the colony after rolling for every disaster in
+// the table, along with an event for each disaster that struck.
+//
+// Each disaster draws exactly one number from rng, whether or not it
+// strikes, so a fixed seed always produces the same outcomes. A disaster
+// strikes when the number is less than its probability; a probability of
+// 1 always strikes and 0 never does. When it strikes, each population
+// group loses its share of casualties, rounded down, and the capacity
+// loses its share, rounded down. Civilians lose theirs as in natural
+// deaths, so the garrison dies last, and frozen civilians are spared. A frozen colony still draws its numbers
+// but is returned unchanged, with no events.
+func (c Colony) ApplyDisasters(rng Rng, table DisasterTable) (Colony, []Event) {
+	var events []Event
+	for _, d := range table {
+		if !(rng.Float64() < d.Probability) || c.frozen {
+			continue
+		}
+		event := Event{Name: d.Name}
+		members := make([]Unit, 0, len(c.members))
+		for _, u := range c.members {
+			if pg, ok := u.(PopulationGroup); ok {
+				u = killUnit(u, int(float64(pg.Population())*clamp(d.Casualties, 0, 1)))
+				event.Deaths += pg.Population() - u.(PopulationGroup).Population()
+			}
+			members = append(members, u)
+		}
+		c.members = members
+		event.CapacityLost = int(float64(c.capacity) * clamp(d.CapacityLoss, 0, 1))
+		c.capacity -= event.CapacityLost
+		events = append(events, event)
+	}
+	return c, events
+}
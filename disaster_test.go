@@ -0,0 +1,89 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestColonyApplyDisasters(t *testing.T) {
+	c := wge.NewColony(10_000, wge.NewCivilian(5_000, 5), wge.NewSoldier(1_000, 5))
+	table := wge.DisasterTable{
+		{Name: "meteor strike", Probability: 1, Casualties: 0.10, CapacityLoss: 0.20},
+		{Name: "reactor failure", Probability: 0, Casualties: 0.50},
+	}
+	for seed := uint64(0); seed < 20; seed++ {
+		got, events := c.ApplyDisasters(wge.NewRng(seed), table)
+		if len(events) != 1 || events[0].Name != "meteor strike" {
+			t.Fatalf("disasters: %d: expected a meteor strike, got %+v\n", seed, events)
+		}
+		if events[0].Deaths != 600 || events[0].CapacityLost != 2_000 {
+			t.Errorf("disasters: %d: expected 600 deaths and 2000 capacity, got %+v\n", seed, events[0])
+		}
+		if got.Population() != 5_400 || got.Capacity() != 8_000 {
+			t.Errorf("disasters: %d: expected 5400 in 8000, got %d in %d\n", seed, got.Population(), got.Capacity())
+		}
+	}
+	if c.Population() != 6_000 || c.Capacity() != 10_000 {
+		t.Errorf("disasters: original: expected 6000 in 10000, got %d in %d\n", c.Population(), c.Capacity())
+	}
+
+	// a frozen colony is spared, but still draws its numbers
+	frozen, rng, fresh := c.WithFrozen(true), wge.NewRng(7), wge.NewRng(7)
+	if got, events := frozen.ApplyDisasters(rng, table); len(events) != 0 || !reflect.DeepEqual(got, frozen) {
+		t.Errorf("disasters: frozen: expected no change, got %+v with %+v\n", got, events)
+	}
+	_, _ = fresh.Float64(), fresh.Float64()
+	if a, b := rng.Float64(), fresh.Float64(); a != b {
+		t.Errorf("disasters: frozen: expected 2 draws, got a different stream\n")
+	}
+
+	// the same seed gives the same outcomes
+	coinFlip := wge.DisasterTable{{Name: "quake", Probability: 0.5, Casualties: 0.01}}
+	a, b := wge.NewRng(99), wge.NewRng(99)
+	fired := 0
+	for turn := 0; turn < 100; turn++ {
+		_, ea := c.ApplyDisasters(a, coinFlip)
+		_, eb := c.ApplyDisasters(b, coinFlip)
+		if !reflect.DeepEqual(ea, eb) {
+			t.Fatalf("disasters: turn %d: expected %+v, got %+v\n", turn, ea, eb)
+		}
+		fired += len(ea)
+	}
+	if !(25 < fired && fired < 75) {
+		t.Errorf("disasters: coin flip: expected about 50, got %d\n", fired)
+	}
+}
+
+func TestColonyApplyDisastersGarrison(t *testing.T) {
+	// the garrison doesn't shield the colony, it just dies last
+	p, _ := wge.NewCivilian(1_000, 5).WithGarrison(600)
+	c := wge.NewColony(10_000, p)
+	table := wge.DisasterTable{{Name: "plague", Probability: 1, Casualties: 0.90}}
+	got, events := c.ApplyDisasters(wge.NewRng(1), table)
+	if len(events) != 1 || events[0].Deaths != 900 {
+		t.Fatalf("disasters: garrison: expected 900 deaths, got %+v\n", events)
+	}
+	if got.Population() != 100 {
+		t.Errorf("disasters: garrison: expected 100 survivors, got %d\n", got.Population())
+	} else if g := got.Members()[0].(wge.Civilian).Garrison(); g != 100 {
+		t.Errorf("disasters: garrison: expected 100 garrison, got %d\n", g)
+	}
+}
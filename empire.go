@@ -0,0 +1,93 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge
+
+// Empire is the set of star systems held by a single player.
+type Empire struct {
+	systems []System
+}
+
+// EmpireSummary is the roll up of an empire for reports.
+type EmpireSummary struct {
+	Systems  int
+	Colonies int
+	// Population and Rebels are totals across every colony.
+	Population int64
+	Rebels     int64
+	// AverageTech is the tech level of the population, weighted by the
+	// number of people at each level. It is 0 for an empty empire.
+	AverageTech float64
+	// FoodNeeded and LifeSupportNeeded are the totals needed per turn.
+	FoodNeeded        float64
+	LifeSupportNeeded float64
+	// AtRisk is the number of colonies at risk of rebellion.
+	AtRisk int
+}
+
+// rebellionRiskFraction is the fraction of rebels at which a colony is
+// counted as at risk of rebellion.
+const rebellionRiskFraction = 0.25
+
+// NewEmpire returns an empire with the given systems.
+func NewEmpire(systems ...System) Empire {
+	return Empire{
+		systems: append([]System(nil), systems...),
+	}
+}
+
+// Summary returns the totals for every colony in the empire. A colony is
+// at risk of rebellion when at least a quarter of its population are
+// rebels. The tech level is averaged over the units that have both a
+// population and a tech level. The empire is not changed.
+func (e Empire) Summary() EmpireSummary {
+	sum := EmpireSummary{Systems: len(e.systems)}
+	var techPopulation, techTotal int64
+	for _, s := range e.systems {
+		for _, c := range s.colonies {
+			sum.Colonies++
+			pop, rebels := c.Population64(), int64(c.Rebels())
+			sum.Population += pop
+			sum.Rebels += rebels
+			sum.FoodNeeded += c.FoodNeeded()
+			sum.LifeSupportNeeded += c.LifeSupportNeeded()
+			if pop > 0 && float64(rebels) >= rebellionRiskFraction*float64(pop) {
+				sum.AtRisk++
+			}
+			for _, u := range c.members {
+				pg, ok := u.(PopulationGroup)
+				if !ok {
+					continue
+				}
+				tl, ok := u.(TechLevel)
+				if !ok {
+					continue
+				}
+				techPopulation += int64(pg.Population())
+				techTotal += int64(pg.Population()) * int64(tl.TechLevel())
+			}
+		}
+	}
+	if techPopulation > 0 {
+		sum.AverageTech = float64(techTotal) / float64(techPopulation)
+	}
+	return sum
+}
+
+// Systems returns a copy of the systems in the empire.
+func (e Empire) Systems() []System {
+	return append([]System(nil), e.systems...)
+}
@@ -0,0 +1,56 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge_test
+
+import (
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestEmpireSummary(t *testing.T) {
+	home := wge.NewColony(100_000, wge.NewCivilian(30_000, 6), wge.NewSoldier(10_000, 6))
+	restless := wge.NewColony(50_000, wge.CivilianFromHeadcount(20_000, 8_000, 3))
+	outpost := wge.NewColony(10_000)
+	e := wge.NewEmpire(wge.NewSystem(home), wge.NewSystem(restless, outpost))
+	sum := e.Summary()
+	if sum.Systems != 2 || sum.Colonies != 3 {
+		t.Errorf("summary: counts: expected 2/3, got %d/%d\n", sum.Systems, sum.Colonies)
+	}
+	if sum.Population != 60_000 {
+		t.Errorf("summary: population: expected 60000, got %d\n", sum.Population)
+	}
+	if sum.Rebels != 8_000 {
+		t.Errorf("summary: rebels: expected 8000, got %d\n", sum.Rebels)
+	}
+	// (40,000 * 6 + 20,000 * 3) / 60,000
+	if !isClose(5.0, sum.AverageTech) {
+		t.Errorf("summary: tech: expected 5.0, got %f\n", sum.AverageTech)
+	}
+	if expect := home.FoodNeeded() + restless.FoodNeeded(); !isClose(expect, sum.FoodNeeded) {
+		t.Errorf("summary: food: expected %f, got %f\n", expect, sum.FoodNeeded)
+	}
+	if expect := home.LifeSupportNeeded() + restless.LifeSupportNeeded(); !isClose(expect, sum.LifeSupportNeeded) {
+		t.Errorf("summary: life support: expected %f, got %f\n", expect, sum.LifeSupportNeeded)
+	}
+	if sum.AtRisk != 1 {
+		t.Errorf("summary: at risk: expected 1, got %d\n", sum.AtRisk)
+	}
+	if got := wge.NewEmpire().Summary(); got != (wge.EmpireSummary{}) {
+		t.Errorf("summary: empty: expected zero summary, got %+v\n", got)
+	}
+}
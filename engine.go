@@ -0,0 +1,128 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge
+
+// Engine runs the turns for a system.
+type Engine struct {
+	system  System
+	turn    int
+	rng     *splitMix64
+	journal *Journal
+}
+
+// TurnInput is the set of decisions that drive a turn.
+type TurnInput struct {
+	// StandardOfLiving is the base standard of living for every colony.
+	StandardOfLiving float64
+	// Variance is the largest random change to the standard of living,
+	// as a fraction. Each colony draws its own change every turn.
+	Variance float64
+}
+
+// TurnResult is the outcome of a turn.
+type TurnResult struct {
+	Turn       int            // the turn that was run
+	Population int            // population of the system after the turn
+	Rebels     int            // rebels in the system after the turn
+	Entries    []JournalEntry // inputs and outputs for each colony
+}
+
+// NewEngine returns an engine for the system with a random number
+// generator started from the seed.
+func NewEngine(s System, seed uint64) *Engine {
+	return &Engine{
+		system: NewSystem(s.colonies...),
+		rng:    &splitMix64{state: seed},
+	}
+}
+
+// Record starts recording every turn to the journal.
+// Passing nil stops recording.
+func (e *Engine) Record(j *Journal) {
+	e.journal = j
+}
+
+// Simulate returns the result that Step would return for the input
+// without changing the engine. It runs the turn on a copy of the engine
+// with a fork of the random number generator, so the next Step sees the
+// same random numbers, and it does not write to the journal.
+func (e *Engine) Simulate(input TurnInput) TurnResult {
+	fork := &Engine{
+		system: NewSystem(e.system.colonies...),
+		turn:   e.turn,
+		rng:    &splitMix64{state: e.rng.state},
+	}
+	return fork.Step(input)
+}
+
+// Step runs one turn for every colony in the system.
+//
+// Each colony gets its own seed from the engine's generator. The seed
+// drives the random change to the standard of living, and the colony then
+// applies the turn at its current capacity with Colony.ApplyTurnAt for
+// the engine's turn, so members founded on a recent turn get their grace
+// period from overcrowding. When the engine is recording, the inputs and
+// outputs for each colony are added to the journal.
+func (e *Engine) Step(input TurnInput) TurnResult {
+	e.turn++
+	result := TurnResult{Turn: e.turn}
+	colonies := make([]Colony, len(e.system.colonies))
+	for i, c := range e.system.colonies {
+		entry := JournalEntry{
+			Turn:         e.turn,
+			Colony:       i,
+			BaseStandard: input.StandardOfLiving,
+			Variance:     input.Variance,
+			Seed:         e.rng.Uint64(),
+			Capacity:     c.Capacity(),
+			PctCapacity:  c.PctCapacity(),
+		}
+		entry.StandardOfLiving = varyStandard(entry.Seed, entry.BaseStandard, entry.Variance)
+		colonies[i] = c.ApplyTurnAt(e.turn, entry.StandardOfLiving)
+		entry.Population, entry.Rebels = colonies[i].Population(), colonies[i].Rebels()
+		entry.Fingerprint = colonies[i].fingerprint()
+		result.Population += entry.Population
+		result.Rebels += entry.Rebels
+		result.Entries = append(result.Entries, entry)
+	}
+	e.system.colonies = colonies
+	if e.journal != nil {
+		e.journal.Entries = append(e.journal.Entries, result.Entries...)
+	}
+	return result
+}
+
+// System returns the current state of the system.
+func (e *Engine) System() System {
+	return NewSystem(e.system.colonies...)
+}
+
+// Turn returns the number of turns the engine has run.
+func (e *Engine) Turn() int {
+	return e.turn
+}
+
+// varyStandard returns the standard of living after the random change
+// drawn from the seed. The change is uniform in ±variance of the base,
+// and the result is at least 0.01, as in BlendStandard.
+func varyStandard(seed uint64, baseStandard, variance float64) float64 {
+	if variance <= 0 {
+		return baseStandard
+	}
+	r := NewRng(seed)
+	return floorStandard(baseStandard * (1 + variance*(2*r.Float64()-1)))
+}
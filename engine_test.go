@@ -0,0 +1,58 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestEngineSimulate(t *testing.T) {
+	e := wge.NewEngine(wge.NewSystem(
+		wge.NewColony(20_000, wge.NewCivilian(10_000, 5)),
+		wge.NewColony(5_000, wge.NewCivilian(4_900, 3)),
+	), 7)
+	var journal wge.Journal
+	e.Record(&journal)
+	e.Step(wge.TurnInput{StandardOfLiving: 1.0, Variance: 0.25})
+
+	turn, system, entries := e.Turn(), e.System(), len(journal.Entries)
+	input := wge.TurnInput{StandardOfLiving: 1.2, Variance: 0.25}
+	expect := e.Simulate(input)
+	// try a few other inputs, as an AI would
+	for _, sol := range []float64{0.5, 0.8, 1.5} {
+		e.Simulate(wge.TurnInput{StandardOfLiving: sol, Variance: 0.25})
+	}
+	if e.Turn() != turn {
+		t.Errorf("simulate: turn: expected %d, got %d\n", turn, e.Turn())
+	}
+	if !reflect.DeepEqual(e.System(), system) {
+		t.Errorf("simulate: system: expected %+v, got %+v\n", system, e.System())
+	}
+	if len(journal.Entries) != entries {
+		t.Errorf("simulate: journal: expected %d entries, got %d\n", entries, len(journal.Entries))
+	}
+
+	if got := e.Step(input); !reflect.DeepEqual(got, expect) {
+		t.Errorf("simulate: step: expected %+v, got %+v\n", expect, got)
+	}
+	if e.Turn() != turn+1 {
+		t.Errorf("simulate: step: turn: expected %d, got %d\n", turn+1, e.Turn())
+	}
+}
@@ -0,0 +1,103 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge
+
+import "sort"
+
+// maxFactions is the number of named rebel factions a unit can track.
+const maxFactions = 4
+
+// Faction is a named group of rebels.
+type Faction struct {
+	Name   string
+	Rebels int
+}
+
+// factions holds the named rebel factions of a unit, sorted by name, with
+// the unused slots at the end. It is an array rather than a slice so that
+// units stay comparable values that are safe to copy.
+//
+// Rebels that don't belong to a named faction are kept in the default
+// faction, which is the rebel count in the unit itself.
+type factions [maxFactions]Faction
+
+// add returns the factions with rebels added to the named faction,
+// creating it if needed. Factions that reach zero are removed.
+// It returns false if there is no room for a new faction.
+func (f factions) add(name string, rebels int) (factions, bool) {
+	for i := range f {
+		if f[i].Name == name {
+			f[i].Rebels += rebels
+			return f.compact(), true
+		}
+	}
+	if rebels == 0 {
+		return f, true
+	}
+	for i := range f {
+		if f[i].Name == "" {
+			f[i] = Faction{Name: name, Rebels: rebels}
+			return f.compact(), true
+		}
+	}
+	return f, false
+}
+
+// compact removes empty factions and sorts the rest by name.
+func (f factions) compact() factions {
+	var n factions
+	i := 0
+	for _, faction := range f {
+		if faction.Name != "" && faction.Rebels != 0 {
+			n[i], i = faction, i+1
+		}
+	}
+	sort.Slice(n[:i], func(a, b int) bool {
+		return n[a].Name < n[b].Name
+	})
+	return n
+}
+
+// get returns the number of rebels in the named faction.
+func (f factions) get(name string) int {
+	for _, faction := range f {
+		if faction.Name == name {
+			return faction.Rebels
+		}
+	}
+	return 0
+}
+
+// list returns a copy of the named factions.
+func (f factions) list() []Faction {
+	var list []Faction
+	for _, faction := range f {
+		if faction.Name != "" {
+			list = append(list, faction)
+		}
+	}
+	return list
+}
+
+// total returns the number of rebels in the named factions.
+func (f factions) total() int {
+	total := 0
+	for _, faction := range f {
+		total += faction.Rebels
+	}
+	return total
+}
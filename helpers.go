@@ -16,16 +16,110 @@
 
 package wge
 
-import "math"
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Clamp returns v limited to the range lo to hi, inclusive.
+// Callers can use it to put standard of living and percent capacity
+// into the ranges expected by the rate functions.
+//
+// The range is not checked. If lo > hi, values below lo return lo
+// and all other values return hi.
+func Clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	} else if hi < v {
+		return hi
+	}
+	return v
+}
+
+// asBool coerces a scripting value to a bool.
+// It accepts bools and the strings accepted by strconv.ParseBool.
+func asBool(v any) (bool, error) {
+	switch v := v.(type) {
+	case bool:
+		return v, nil
+	case string:
+		return strconv.ParseBool(strings.TrimSpace(v))
+	}
+	return false, fmt.Errorf("%v: can't convert %T to bool", v, v)
+}
+
+// asFloat coerces a scripting value to a float64.
+// It accepts any number type, json.Number, and numeric strings.
+func asFloat(v any) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	}
+	return 0, fmt.Errorf("%v: can't convert %T to number", v, v)
+}
+
+// asInt coerces a scripting value to an int.
+// It accepts any number type, json.Number, and numeric strings,
+// but not values with a fractional part or outside the range of an int.
+func asInt(v any) (int, error) {
+	switch v := v.(type) {
+	case int:
+		return v, nil
+	case int32:
+		return int(v), nil
+	case int64:
+		return int(v), nil
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n, nil
+		}
+	}
+	f, err := asFloat(v)
+	if err != nil {
+		return 0, err
+	} else if f != math.Trunc(f) {
+		return 0, fmt.Errorf("%v: not an integer", v)
+	} else if !(math.MinInt <= f && f < math.MaxInt) { // MaxInt rounds up to 2^63
+		return 0, fmt.Errorf("%v: out of range for an int", v)
+	}
+	return int(f), nil
+}
 
 // clamp values to a range
 func clamp(a, min, max float64) float64 {
-	if a < min {
-		return min
-	} else if max < a {
-		return max
+	return Clamp(a, min, max)
+}
+
+// dashboardBar returns a bar of width characters, filled with '#' for
+// the fraction (clamped to 0 to 1) and with '.' for the rest.
+func dashboardBar(fraction float64, width int) string {
+	filled := int(clamp(fraction, 0, 1)*float64(width) + 0.5)
+	return strings.Repeat("#", filled) + strings.Repeat(".", width-filled)
+}
+
+// floorStandard limits a standard of living to at least 0.01. There is no
+// upper limit; the rate functions clamp the standard to the StandardRange
+// of their config, which may be wider than the default 0.01 to 3.0.
+func floorStandard(v float64) float64 {
+	if !(v > 0.01) {
+		return 0.01
 	}
-	return a
+	return v
 }
 
 // isClose returns true if a and b are practically the same.
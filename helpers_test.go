@@ -16,7 +16,35 @@
 
 package wge_test
 
-import "math"
+import (
+	"math"
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestClamp(t *testing.T) {
+	for _, tc := range []struct {
+		id        int
+		v, lo, hi float64
+		expect    float64
+	}{
+		{1, -1.0, 0.01, 3.0, 0.01},
+		{2, 0.01, 0.01, 3.0, 0.01},
+		{3, 1.5, 0.01, 3.0, 1.5},
+		{4, 3.0, 0.01, 3.0, 3.0},
+		{5, 4.0, 0.01, 3.0, 3.0},
+		// when lo > hi, values below lo return lo, all others return hi
+		{6, 0.5, 2.0, 1.0, 2.0},
+		{7, 1.5, 2.0, 1.0, 2.0},
+		{8, 2.5, 2.0, 1.0, 1.0},
+	} {
+		got := wge.Clamp(tc.v, tc.lo, tc.hi)
+		if !isClose(tc.expect, got) {
+			t.Errorf("clamp: %d: expected %8.4f, got %8.4f\n", tc.id, tc.expect, got)
+		}
+	}
+}
 
 // isClose returns true if a and b are practically the same.
 // epsilon is 1e-8 for the comparison.
@@ -0,0 +1,71 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge
+
+import "fmt"
+
+// Journal is a record of the inputs and outputs of every colony turn
+// run by an Engine. Replaying a journal against the starting system must
+// reproduce the same end state; the first entry that doesn't is where
+// two clients disagree.
+type Journal struct {
+	Entries []JournalEntry `json:"entries"`
+}
+
+// JournalEntry is the record of one colony's turn.
+type JournalEntry struct {
+	Turn   int `json:"turn"`
+	Colony int `json:"colony"` // index of the colony in the system
+
+	// inputs
+	BaseStandard     float64 `json:"base-standard"`
+	Variance         float64 `json:"variance,omitempty"`
+	Seed             uint64  `json:"seed"`
+	StandardOfLiving float64 `json:"standard-of-living"` // after the random change
+	Capacity         int     `json:"capacity"`
+	PctCapacity      float64 `json:"pct-capacity"`
+
+	// outputs
+	Population  int    `json:"population"`
+	Rebels      int    `json:"rebels"`
+	Fingerprint uint64 `json:"fingerprint"`
+}
+
+// Replay applies every entry in the journal to the system and returns
+// the end state. It returns an error at the first entry whose inputs
+// or outputs don't match what the system produces.
+func (j Journal) Replay(s System) (System, error) {
+	colonies := append([]Colony(nil), s.colonies...)
+	for _, entry := range j.Entries {
+		if entry.Colony < 0 || entry.Colony >= len(colonies) {
+			return s, fmt.Errorf("replay: turn %d: colony %d: no such colony", entry.Turn, entry.Colony)
+		}
+		c := colonies[entry.Colony]
+		if c.Capacity() != entry.Capacity || c.PctCapacity() != entry.PctCapacity {
+			return s, fmt.Errorf("replay: turn %d: colony %d: capacity %d (%g): want %d (%g)", entry.Turn, entry.Colony, c.Capacity(), c.PctCapacity(), entry.Capacity, entry.PctCapacity)
+		}
+		if sol := varyStandard(entry.Seed, entry.BaseStandard, entry.Variance); sol != entry.StandardOfLiving {
+			return s, fmt.Errorf("replay: turn %d: colony %d: standard of living %g: want %g", entry.Turn, entry.Colony, sol, entry.StandardOfLiving)
+		}
+		c = c.ApplyTurnAt(entry.Turn, entry.StandardOfLiving)
+		if fp := c.fingerprint(); fp != entry.Fingerprint {
+			return s, fmt.Errorf("replay: turn %d: colony %d: fingerprint %016x: want %016x", entry.Turn, entry.Colony, fp, entry.Fingerprint)
+		}
+		colonies[entry.Colony] = c
+	}
+	return NewSystem(colonies...), nil
+}
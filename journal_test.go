@@ -0,0 +1,90 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestJournalReplay(t *testing.T) {
+	start := wge.NewSystem(
+		wge.NewColony(20_000, wge.NewCivilian(10_000, 5), wge.NewSoldier(500, 5)),
+		wge.NewColony(5_000, wge.NewCivilian(4_900, 3), wge.NewCivilian(300, 7)),
+	)
+	e := wge.NewEngine(start, 42)
+	var journal wge.Journal
+	e.Record(&journal)
+	for turn := 0; turn < 10; turn++ {
+		e.Step(wge.TurnInput{StandardOfLiving: 1.0, Variance: 0.25})
+	}
+	if len(journal.Entries) != 20 {
+		t.Fatalf("journal: entries: expected 20, got %d\n", len(journal.Entries))
+	}
+
+	// the journal must survive a trip through json
+	data, err := json.Marshal(journal)
+	if err != nil {
+		t.Fatalf("journal: marshal: expected nil, got %v\n", err)
+	}
+	var replay wge.Journal
+	if err := json.Unmarshal(data, &replay); err != nil {
+		t.Fatalf("journal: unmarshal: expected nil, got %v\n", err)
+	}
+
+	end, err := replay.Replay(start)
+	if err != nil {
+		t.Fatalf("journal: replay: expected nil, got %v\n", err)
+	}
+	expect, got := e.System().Colonies(), end.Colonies()
+	for i := range expect {
+		if !reflect.DeepEqual(expect[i].Members(), got[i].Members()) {
+			t.Errorf("journal: replay: colony %d: expected %+v, got %+v\n", i, expect[i].Members(), got[i].Members())
+		}
+	}
+
+	// a client with a different seed disagrees on the first turn
+	replay.Entries[0].Seed++
+	if _, err := replay.Replay(start); err == nil {
+		t.Errorf("journal: replay: bad seed: expected error, got nil\n")
+	}
+}
+
+func TestJournalReplayDesync(t *testing.T) {
+	// two starts that differ only in how hardened the rebels are, which
+	// doesn't change the headcounts of a restive colony
+	var calm, hardened wge.Civilian
+	if err := json.Unmarshal([]byte(`{"loyal-citizens":2000,"rebel-citizens":8000,"tech-level":5}`), &calm); err != nil {
+		t.Fatalf("desync: calm: expected nil, got %v\n", err)
+	} else if err := json.Unmarshal([]byte(`{"loyal-citizens":2000,"rebel-citizens":8000,"tech-level":5,"radicalization":0.3}`), &hardened); err != nil {
+		t.Fatalf("desync: hardened: expected nil, got %v\n", err)
+	}
+	start := wge.NewSystem(wge.NewColony(20_000, calm, wge.NewSoldier(500, 5)))
+	e := wge.NewEngine(start, 42)
+	var journal wge.Journal
+	e.Record(&journal)
+	e.Step(wge.TurnInput{StandardOfLiving: 1.0})
+	if _, err := journal.Replay(start); err != nil {
+		t.Fatalf("desync: replay: expected nil, got %v\n", err)
+	}
+	if _, err := journal.Replay(wge.NewSystem(wge.NewColony(20_000, hardened, wge.NewSoldier(500, 5)))); err == nil {
+		t.Errorf("desync: radicalization: expected error, got nil\n")
+	}
+}
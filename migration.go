@@ -0,0 +1,48 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge
+
+// MigrationFlow returns the number of civilians that would voluntarily move
+// from one colony to another this turn.
+//
+// People move toward a higher standard of living. Each point of difference
+// in the standard of living moves 5% of the civilians in the source colony,
+// not counting garrisons, scaled by the fraction of the destination's
+// capacity that is free.
+// The flow never exceeds the free space at the destination, or what is
+// left of the destination's immigration quota for the turn.
+// If the destination is no better off, or is full, no one moves.
+func MigrationFlow(from, to Colony, standardFrom, standardTo float64) int {
+	const ratePerPoint = 0.05
+	gradient := standardTo - standardFrom
+	if gradient <= 0 {
+		return 0
+	}
+	free := to.capacity - to.Population()
+	if free <= 0 {
+		return 0
+	}
+	pctFree := 1 - to.PctCapacity()
+	flow := int(float64(from.migrants()) * ratePerPoint * gradient * pctFree)
+	if flow > free {
+		flow = free
+	}
+	if room := to.immigrationRoom(); room >= 0 && flow > room {
+		flow = room
+	}
+	return flow
+}
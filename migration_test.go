@@ -0,0 +1,104 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge_test
+
+import (
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestMigrationFlow(t *testing.T) {
+	crowded := wge.NewColony(10_000, wge.NewCivilian(9_500, 5))
+	roomy := wge.NewColony(100_000, wge.NewCivilian(20_000, 5))
+	full := wge.NewColony(10_000, wge.NewCivilian(10_000, 5))
+	huge := wge.NewColony(2_000_000, wge.NewCivilian(1_000_000, 5))
+
+	for _, tc := range []struct {
+		id           int
+		from, to     wge.Colony
+		stdFrom, std float64
+		expect       int
+	}{
+		// 9,500 * 5% * 1.0 * 80% free
+		{1, crowded, roomy, 0.5, 1.5, 380},
+		// the reverse direction has a negative gradient
+		{2, roomy, crowded, 1.5, 0.5, 0},
+		// equal standards produce no flow
+		{3, crowded, roomy, 1.0, 1.0, 0},
+		// a full destination takes no one
+		{4, crowded, full, 0.5, 1.5, 0},
+		// the flow is limited by the free space at the destination
+		{5, huge, crowded, 0.5, 3.0, 500},
+	} {
+		if got := wge.MigrationFlow(tc.from, tc.to, tc.stdFrom, tc.std); tc.expect != got {
+			t.Errorf("migrationFlow: %d: expected %d, got %d\n", tc.id, tc.expect, got)
+		}
+	}
+}
+
+func TestImmigrationQuota(t *testing.T) {
+	from := wge.NewColony(1_000_000, wge.NewCivilian(1_000_000, 5))
+	to := wge.NewColony(100_000, wge.NewCivilian(1_000, 5)).WithImmigrationQuota(100, wge.BounceBack)
+	if got := wge.MigrationFlow(from, to, 0.5, 2.0); got != 100 {
+		t.Errorf("quota: flow: expected 100, got %d\n", got)
+	}
+
+	// 500 inbound, 100 admitted, and 400 bounced back
+	c, bounced, err := to.Deliver(wge.NewCivilian(500, 5))
+	if err != nil {
+		t.Fatalf("quota: deliver: expected nil, got %v\n", err)
+	}
+	if got := c.Population(); got != 1_100 {
+		t.Errorf("quota: deliver: expected 1100, got %d\n", got)
+	}
+	if pg, ok := bounced.(wge.PopulationGroup); !ok || pg.Population() != 400 {
+		t.Errorf("quota: deliver: expected 400 bounced, got %+v\n", bounced)
+	}
+	// the quota is used up for this turn
+	if _, bounced, _ := c.Deliver(wge.NewCivilian(50, 5)); bounced == nil || bounced.(wge.PopulationGroup).Population() != 50 {
+		t.Errorf("quota: deliver: full: expected 50 bounced, got %+v\n", bounced)
+	}
+	if got := wge.MigrationFlow(from, c, 0.5, 2.0); got != 0 {
+		t.Errorf("quota: flow: full: expected 0, got %d\n", got)
+	}
+	// and is reset by the next turn
+	if got := wge.MigrationFlow(from, c.ApplyTurn(1.0), 0.5, 2.0); got != 100 {
+		t.Errorf("quota: flow: next turn: expected 100, got %d\n", got)
+	}
+
+	// turned away people are lost
+	c, bounced, err = to.WithImmigrationQuota(100, wge.TurnAway).Deliver(wge.NewCivilian(500, 5))
+	if err != nil || bounced != nil || c.Population() != 1_100 {
+		t.Errorf("quota: turn away: expected 1100 and nothing bounced, got %d %+v %v\n", c.Population(), bounced, err)
+	}
+}
+
+func TestImmigrationQuotaGarrison(t *testing.T) {
+	// a garrison can't be split off, so it goes back with the overflow
+	arrivals, _ := wge.NewCivilian(500, 5).WithGarrison(400)
+	c := wge.NewColony(10_000).WithImmigrationQuota(300, wge.BounceBack)
+	got, bounced, err := c.Deliver(arrivals)
+	if err != nil {
+		t.Fatalf("quota: garrison: expected nil, got %v\n", err)
+	} else if got.Population() != 100 {
+		t.Errorf("quota: garrison: expected 100 admitted, got %d\n", got.Population())
+	}
+	if p, ok := bounced.(wge.Civilian); !ok || p.Population() != 400 || p.Garrison() != 400 {
+		t.Errorf("quota: garrison: expected 400 bounced with their garrison, got %+v\n", bounced)
+	}
+}
@@ -0,0 +1,133 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge
+
+import (
+	"sort"
+)
+
+// compile time checks that MultiTechPopulation implements the interfaces
+var (
+	_ PopulationGroup = MultiTechPopulation{}
+)
+
+// MultiTechPopulation is a federation of civilian cohorts that keep their
+// own tech levels. Merge averages the tech levels of two units and makes
+// the group that loses levels discontent; a federation avoids both by
+// keeping one cohort per tech level. It answers the PopulationGroup
+// questions for all of its cohorts together.
+//
+// Cohorts at the same tech level are combined with Civilian.MergeWith,
+// using a config that adds no rebels, since no one loses tech.
+type MultiTechPopulation struct {
+	cohorts []Civilian // sorted by tech level, at most one per level
+}
+
+// Cohort returns the cohort at the tech level and true, or an empty unit
+// and false if the federation has no one at that level.
+func (m MultiTechPopulation) Cohort(techLevel int) (Civilian, bool) {
+	for _, p := range m.cohorts {
+		if p.techLevel == techLevel {
+			return p, true
+		}
+	}
+	return Civilian{}, false
+}
+
+// Cohorts returns a copy of the cohorts, ordered by tech level.
+func (m MultiTechPopulation) Cohorts() []Civilian {
+	return append([]Civilian(nil), m.cohorts...)
+}
+
+// Federate returns a copy of the federation with the unit added. It joins
+// the cohort at its tech level, or starts a new one. Extinct units are
+// ignored.
+func (m MultiTechPopulation) Federate(p Civilian) MultiTechPopulation {
+	if p.IsExtinct() {
+		return m
+	}
+	cfg := defaultRateConfig
+	cfg.MergeMinRebels = 0
+	cohorts := make([]Civilian, 0, len(m.cohorts)+1)
+	joined := false
+	for _, cohort := range m.cohorts {
+		if cohort.techLevel == p.techLevel {
+			cohort, joined = cohort.MergeWith(p, cfg), true
+		}
+		cohorts = append(cohorts, cohort)
+	}
+	if !joined {
+		cohorts = append(cohorts, p)
+		sort.Slice(cohorts, func(i, j int) bool {
+			return cohorts[i].techLevel < cohorts[j].techLevel
+		})
+	}
+	m.cohorts = cohorts
+	return m
+}
+
+// FoodNeeded implements the PopulationGroup interface.
+// It is the total for the cohorts, each at its own tech level.
+func (m MultiTechPopulation) FoodNeeded() float64 {
+	var food float64
+	for _, p := range m.cohorts {
+		food += p.FoodNeeded()
+	}
+	return food
+}
+
+// LifeSupportNeeded implements the PopulationGroup interface.
+// It is the total for the cohorts, each at its own tech level.
+func (m MultiTechPopulation) LifeSupportNeeded() float64 {
+	var ls float64
+	for _, p := range m.cohorts {
+		ls += p.LifeSupportNeeded()
+	}
+	return ls
+}
+
+// NaturalDeathRate implements the PopulationGroup interface.
+// It is the average of the cohorts' rates, weighted by population.
+func (m MultiTechPopulation) NaturalDeathRate(standardOfLiving, pctCapacity float64) float64 {
+	var deaths float64
+	pop := m.Population()
+	if pop == 0 {
+		return 0
+	}
+	for _, p := range m.cohorts {
+		deaths += float64(p.Population()) * p.NaturalDeathRate(standardOfLiving, pctCapacity)
+	}
+	return deaths / float64(pop)
+}
+
+// Population implements the PopulationGroup interface.
+func (m MultiTechPopulation) Population() int {
+	pop := 0
+	for _, p := range m.cohorts {
+		pop += p.Population()
+	}
+	return pop
+}
+
+// Rebels implements the PopulationGroup interface.
+func (m MultiTechPopulation) Rebels() int {
+	rebels := 0
+	for _, p := range m.cohorts {
+		rebels += p.Rebels()
+	}
+	return rebels
+}
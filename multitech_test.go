@@ -0,0 +1,57 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge_test
+
+import (
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestFederate(t *testing.T) {
+	rebels, _ := wge.NewCivilianBuilder().Loyal(900).Rebel(100).Tech(8).Build()
+	low, high := wge.NewCivilian(5_000, 2), rebels
+	m := low.Federate(high)
+	if got, expect := m.Population(), low.Population()+high.Population(); got != expect {
+		t.Errorf("federate: population: expected %d, got %d\n", expect, got)
+	}
+	if got, expect := m.Rebels(), low.Rebels()+high.Rebels(); got != expect {
+		t.Errorf("federate: rebels: expected %d, got %d\n", expect, got)
+	}
+	if got, expect := m.FoodNeeded(), low.FoodNeeded()+high.FoodNeeded(); !isClose(expect, got) {
+		t.Errorf("federate: food: expected %g, got %g\n", expect, got)
+	}
+	// each cohort keeps its tech level and its people
+	cohorts := m.Cohorts()
+	if len(cohorts) != 2 {
+		t.Fatalf("federate: expected 2 cohorts, got %d\n", len(cohorts))
+	} else if !cohorts[0].Equal(low) || !cohorts[1].Equal(high) {
+		t.Errorf("federate: expected %+v and %+v, got %+v\n", low, high, cohorts)
+	}
+	// a merge would average the tech levels and add rebels
+	if merged := low.Merge(high); merged.TechLevel() == 2 || merged.Rebels() <= m.Rebels() {
+		t.Errorf("federate: merge: expected averaged tech and more rebels, got %+v\n", merged)
+	}
+	// joining a cohort at the same tech level adds no rebels
+	m = m.Federate(wge.NewCivilian(1_000, 2))
+	if cohort, ok := m.Cohort(2); !ok || cohort.Population() != 6_000 || cohort.Rebels() != 0 {
+		t.Errorf("federate: join: expected 6000 with no rebels, got %+v\n", cohort)
+	}
+	if _, ok := m.Cohort(5); ok {
+		t.Errorf("federate: cohort 5: expected false, got true\n")
+	}
+}
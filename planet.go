@@ -0,0 +1,58 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge
+
+// Planet is a world that colonies can be built on.
+type Planet struct {
+	name             string
+	carryingCapacity int // people the planet can support
+	env              Environment
+}
+
+// NewPlanet returns a planet that can support carryingCapacity people.
+func NewPlanet(name string, carryingCapacity int, env Environment) Planet {
+	return Planet{
+		name:             name,
+		carryingCapacity: carryingCapacity,
+		env:              env,
+	}
+}
+
+// CarryingCapacity returns the number of people the planet can support.
+func (pl Planet) CarryingCapacity() int {
+	return pl.carryingCapacity
+}
+
+// Environment returns the condition of the planet outside a closed colony.
+func (pl Planet) Environment() Environment {
+	return pl.env
+}
+
+// Headroom returns the number of people the planet can still support
+// given its current population. It is never negative, so a planet that
+// is over its carrying capacity has no headroom.
+func (pl Planet) Headroom(currentPop int) int {
+	if headroom := pl.carryingCapacity - currentPop; headroom > 0 {
+		return headroom
+	}
+	return 0
+}
+
+// Name returns the name of the planet, which is also its id.
+func (pl Planet) Name() string {
+	return pl.name
+}
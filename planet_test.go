@@ -0,0 +1,72 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge_test
+
+import (
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestPlanetHeadroom(t *testing.T) {
+	pl := wge.NewPlanet("Terra Nova", 100_000, wge.Benign)
+	for _, tc := range []struct {
+		id     int
+		pop    int
+		expect int
+	}{
+		{1, 0, 100_000},
+		{2, 50_000, 50_000},
+		{3, 100_000, 0},
+		{4, 150_000, 0},
+	} {
+		if got := pl.Headroom(tc.pop); got != tc.expect {
+			t.Errorf("headroom: %d: expected %d, got %d\n", tc.id, tc.expect, got)
+		}
+		c := wge.NewColony(200_000, wge.NewCivilian(tc.pop, 5))
+		if got := c.Headroom(pl); got != tc.expect {
+			t.Errorf("headroom: colony: %d: expected %d, got %d\n", tc.id, tc.expect, got)
+		}
+	}
+}
+
+func TestColonyApplyTurnOnPlanet(t *testing.T) {
+	// the colony is at 25% of its capacity, so births outpace deaths,
+	// but the planet can't support anyone else
+	pl := wge.NewPlanet("Terra Nova", 10_000, wge.Benign)
+	c := wge.NewColony(40_000, wge.NewCivilian(10_000, 5))
+	if grown := c.ApplyTurn(1.0); !(grown.Population() > c.Population()) {
+		t.Fatalf("applyTurnOnPlanet: expected uncapped growth, got %d\n", grown.Population())
+	}
+	capped := c
+	for turn := 0; turn < 20; turn++ {
+		capped = capped.ApplyTurnOnPlanet(1.0, pl)
+		if got := capped.Population(); got > pl.CarryingCapacity() {
+			t.Fatalf("applyTurnOnPlanet: turn %d: expected at most %d, got %d\n", turn, pl.CarryingCapacity(), got)
+		}
+	}
+	// deaths still apply, so a colony over the cap shrinks
+	crowded := wge.NewColony(40_000, wge.NewCivilian(12_000, 5))
+	if got := crowded.ApplyTurnOnPlanet(1.0, pl).Population(); !(got < 12_000) {
+		t.Errorf("applyTurnOnPlanet: over: expected less than 12000, got %d\n", got)
+	}
+	// a colony under the cap grows up to it and no further
+	small := wge.NewColony(40_000, wge.NewCivilian(9_990, 5), wge.NewSoldier(5, 5))
+	if got := small.ApplyTurnOnPlanet(1.0, pl).Population(); got != pl.CarryingCapacity() {
+		t.Errorf("applyTurnOnPlanet: under: expected %d, got %d\n", pl.CarryingCapacity(), got)
+	}
+}
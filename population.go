@@ -16,164 +16,166 @@
 
 package wge
 
-import "fmt"
-
 // PopulationGroup defines the interface for working with groups of people.
 type PopulationGroup interface {
 	// FoodNeeded returns the number of FOOD units needed to sustain the population.
 	FoodNeeded() float64
 	// LifeSupportNeeded returns the number of LS units needed to sustain the population.
 	LifeSupportNeeded() float64
-	// NaturalBirthRate returns the percentage of natural births in the group.
-	NaturalBirthRate() float64
 	// NaturalDeathRate returns the percentage of natural deaths in the group.
-	NaturalDeathRate() float64
+	NaturalDeathRate(standardOfLiving, pctCapacity float64) float64
 	// Population returns total population of the unit.
 	Population() int
 	// Rebels returns the number of rebels in the population.
 	Rebels() int
 }
 
-// naturalBirthRate calculates the birth rate for a population.
-// The basic birth rate ranges from 0.25% to 10% of the population.
-// The variation depends on the standard of living as well as the
-// availability of "open" living space in the colony.
-func naturalBirthRate(techLevel int, standardOfLiving, pctCapacity float64, isOnShip, isResortColony bool) float64 {
-	if isOnShip { // births never happen on a ship
-		return 0
-	}
-	// clamp the standard of living and percent capacity
-	standardOfLiving = clamp(standardOfLiving, 0.01, 3.0)
-	pctCapacity = clamp(pctCapacity, 0.01, 1.0)
+// Reproducer defines the interface for population groups that have children.
+// Civilian implements it; Soldier does not, since soldiers don't reproduce.
+type Reproducer interface {
+	// NaturalBirthRate returns the percentage of natural births in the group.
+	NaturalBirthRate(standardOfLiving, pctCapacity float64) float64
+}
 
-	// the base rate is determined by tech level
-	birthRate := float64(11-techLevel) * 0.1
-	if birthRate < 0.0025 {
-		birthRate = 0.0025
-	} else if birthRate > 0.10 {
-		birthRate = 0.10
-	}
+// PopulationSnapshot is a plain copy of the state of a population unit.
+// It has no behavior, so other packages can read it without depending
+// on the unit's methods or json format.
+type PopulationSnapshot struct {
+	Loyal      int
+	Rebel      int
+	Tech       int
+	Population int
+	Rebels     int
+}
 
-	// resort colonies increase the birth rate
-	if isResortColony {
-		birthRate *= 2
+// population64 returns the population of the group as an int64,
+// using Population64 when the group provides it.
+func population64(pg PopulationGroup) int64 {
+	if p, ok := pg.(interface{ Population64() int64 }); ok {
+		return p.Population64()
 	}
+	return int64(pg.Population())
+}
 
-	// standard of living influences it
-	if standardOfLiving < 0.25 {
-		birthRate *= 1.5
-	} else if standardOfLiving < 0.80 {
-		birthRate *= 1.25
-	} else if standardOfLiving < 1.20 {
-		// 80% to 120% is the standard range
-	} else if standardOfLiving > 1.20 {
-		birthRate *= 0.75
-	} else if standardOfLiving > 1.75 {
-		birthRate *= 0.5
+// PctCapacity returns the population as a fraction of the capacity.
+// If capacity is not positive, the colony is treated as full.
+func PctCapacity(population, capacity int) float64 {
+	if capacity <= 0 {
+		return 1
 	}
+	return float64(population) / float64(capacity)
+}
 
-	// overcrowding reduces the birth rate
-	if pctCapacity < 0.25 {
-		birthRate *= 1.25
-	} else if pctCapacity < 0.40 {
-		birthRate *= 1.10
-	} else if pctCapacity < 0.65 {
-		// 40% to 65% is the standard range
-	} else if pctCapacity < 0.70 {
-		birthRate *= 0.90
-	} else if pctCapacity < 0.80 {
-		birthRate *= 0.60
-	} else if pctCapacity < 0.90 {
-		birthRate *= 0.25
-	} else if pctCapacity < 0.95 {
-		birthRate *= 0.1
-	} else {
-		birthRate *= 0.05
+// lifeSupportFactor returns the environment multiplier for life support.
+// Ships carry their own environment and always use 1.0.
+func lifeSupportFactor(onShip bool, env Environment) float64 {
+	if onShip {
+		return 1.0
 	}
+	return env.LifeSupportFactor()
+}
 
-	// birth rate is never less than 0.25% or higher than 10%
-	return clamp(birthRate, 0.0025, 0.10)
+// naturalBirthRate calculates the birth rate for a population
+// using the default rate tables.
+func naturalBirthRate(techLevel int, standardOfLiving, pctCapacity float64, isOnShip, isResortColony bool) float64 {
+	return BirthRateWith(defaultRateConfig, techLevel, standardOfLiving, pctCapacity, isOnShip, isResortColony)
 }
 
-// naturalDeathRate calculates the basic death rate for a population.
-// The rate is based on the tech level, standard of living, and
-// availability of living space in the colony or ship.
+// naturalDeathRate calculates the basic death rate for a population
+// using the default rate tables.
 func naturalDeathRate(techLevel int, standardOfLiving, pctCapacity float64) float64 {
-	// clamp the standard of living and percent capacity
-	standardOfLiving = clamp(standardOfLiving, 0.01, 3.0)
-	pctCapacity = clamp(pctCapacity, 0.01, 1.0)
+	return DeathRateWith(defaultRateConfig, techLevel, standardOfLiving, pctCapacity)
+}
 
-	// the base rate is determined by tech level
-	var deathRate float64
-	switch techLevel {
-	case 0:
-		deathRate = 1_500.0 / 100_000.0
-	case 1:
-		deathRate = 1_400.0 / 100_000.0
-	case 2:
-		deathRate = 1_300.0 / 100_000.0
-	case 3:
-		deathRate = 1_200.0 / 100_000.0
-	case 4:
-		deathRate = 1_100.0 / 100_000.0
-	case 5:
-		deathRate = 1_000.0 / 100_000.0
-	case 6:
-		deathRate = 900.0 / 100_000.0
-	case 7:
-		deathRate = 800.0 / 100_000.0
-	case 8:
-		deathRate = 700.0 / 100_000.0
-	case 9:
-		deathRate = 600.0 / 100_000.0
-	case 10:
-		deathRate = 500.0 / 100_000.0
-	default:
-		panic(fmt.Sprintf("assert(0 <= %d <= 10)", techLevel))
+// productivityFactor returns the fraction of normal output produced by a
+// population with the given number of rebels.
+func productivityFactor(rebels, pop int) float64 {
+	if pop <= 0 {
+		return 1
 	}
+	r := clamp(float64(rebels)/float64(pop), 0, 1)
+	return 1 - r*(1+r)/2
+}
 
-	// standard of living influences it
-	if standardOfLiving > 1.500 {
-		deathRate *= 0.975
-	} else if standardOfLiving > 1.250 {
-		deathRate *= 0.950
-	} else if standardOfLiving > 0.990 {
-		// base rate
-	} else if standardOfLiving > 0.875 {
-		deathRate *= 1.025
-	} else if standardOfLiving > 0.750 {
-		deathRate *= 1.050
-	} else if standardOfLiving > 0.625 {
-		deathRate *= 1.075
-	} else if standardOfLiving > 0.500 {
-		deathRate *= 1.100
-	} else if standardOfLiving > 0.375 {
-		deathRate *= 1.125
-	} else if standardOfLiving > 0.250 {
-		deathRate *= 1.150
-	} else if standardOfLiving > 0.125 {
-		deathRate *= 1.175
+// EquilibriumPopulation returns the steady-state population of an open colony,
+// where natural births no longer outpace natural deaths. The standard of
+// living is held constant and capacity is the number of people the colony
+// can hold.
+//
+// Birth rates fall and death rates rise as the colony fills, so the search
+// returns the smallest population where deaths meet or exceed births.
+// If births outpace deaths even at capacity, it returns capacity.
+// If deaths outpace births in a nearly empty colony, it returns 0.
+func EquilibriumPopulation(techLevel int, standardOfLiving float64, capacity int) int {
+	if capacity <= 0 {
+		return 0
 	}
-
-	// overcrowding increases it
-	if pctCapacity > 2.000 {
-		deathRate *= 3.000
-	} else if pctCapacity > 1.500 {
-		deathRate *= 2.000
-	} else if pctCapacity > 0.990 {
-		deathRate *= 1.500
-	} else if pctCapacity > 0.975 {
-		deathRate *= 1.250
-	} else if pctCapacity > 0.950 {
-		deathRate *= 1.100
-	} else if pctCapacity > 0.925 {
-		deathRate *= 1.025
-	} else if pctCapacity > 0.900 {
-		deathRate *= 1.010
-	} else {
-		// base rate
+	isGrowing := func(pop int) bool {
+		pctCapacity := float64(pop) / float64(capacity)
+		return naturalBirthRate(techLevel, standardOfLiving, pctCapacity, false, false) > naturalDeathRate(techLevel, standardOfLiving, pctCapacity)
+	}
+	if !isGrowing(1) {
+		return 0
+	} else if isGrowing(capacity) {
+		return capacity
 	}
+	// invariant: isGrowing(lo) && !isGrowing(hi)
+	lo, hi := 1, capacity
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		if isGrowing(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi
+}
+
+// BlendStandard returns a standard of living from the ratios of FOOD and
+// consumer goods available to needed. The weights of the two supplies are
+// normalized to add up to 1, so the goods weight is 1 - foodWeight; a
+// spartan society might weight food at 0.9, and the default used by
+// Colony.StandardOfLiving is 0.75. The food weight is clamped to 0 to 1
+// and the result is at least 0.01. There is no upper limit; the rate
+// functions clamp the standard to the StandardRange of their config.
+func BlendStandard(foodRatio, goodsRatio, foodWeight float64) float64 {
+	foodWeight = clamp(foodWeight, 0, 1)
+	return floorStandard(foodWeight*foodRatio + (1-foodWeight)*goodsRatio)
+}
 
-	// death rate is never less than 0.25% or higher than 75%
-	return clamp(deathRate, 0.00_2500, 0.75_0000)
+// EffectiveStandardOfLiving returns the standard of living after taxes.
+//
+// The reduction is linear: every 10% of tax removes 5% of the base
+// standard, so a 100% tax halves it. The tax rate is clamped to 0 to 1
+// and the result is at least 0.01, with no upper limit, as in
+// BlendStandard. Pass the result to ApplyTurn and Discontent so that taxes
+// affect births, deaths, and unrest.
+func EffectiveStandardOfLiving(baseStandard, taxRate float64) float64 {
+	return floorStandard(baseStandard * (1 - 0.5*clamp(taxRate, 0, 1)))
+}
+
+// SmoothStandard returns an exponentially smoothed standard of living.
+// The history holds the raw values from earlier turns, oldest first, and
+// newValue is the raw value for this turn.
+//
+// Alpha, from 0 to 1, is the weight of the newest value: each step the
+// smoothed value moves alpha of the way toward the raw value. An alpha of
+// 1 turns smoothing off and returns newValue. Smaller values respond more
+// slowly; after a step change the smoothed value covers about alpha of
+// the remaining gap each turn, so 0.5 closes 7/8 of the gap in three
+// turns. Alpha is clamped to 0.01 to 1.
+//
+// Pass the result to Colony.ApplyTurn instead of the raw value to keep
+// births and deaths from swinging with every change in supply.
+func SmoothStandard(history []float64, newValue float64, alpha float64) float64 {
+	alpha = clamp(alpha, 0.01, 1)
+	if len(history) == 0 {
+		return newValue
+	}
+	smoothed := history[0]
+	for _, v := range history[1:] {
+		smoothed += alpha * (v - smoothed)
+	}
+	return smoothed + alpha*(newValue-smoothed)
 }
@@ -0,0 +1,138 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge_test
+
+import (
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestEquilibriumPopulation(t *testing.T) {
+	for _, tc := range []struct {
+		id               int
+		techLevel        int
+		standardOfLiving float64
+		capacity         int
+		expect           int
+	}{
+		{1, 10, 0.3, 10_000, 9_501},
+		{2, 10, 0.7, 10_000, 9_751},
+		{3, 0, 1.0, 10_000, 9_000},
+		{4, 5, 1.0, 0, 0},
+	} {
+		got := wge.EquilibriumPopulation(tc.techLevel, tc.standardOfLiving, tc.capacity)
+		if tc.expect != got {
+			t.Errorf("equilibrium: %d: expected %d, got %d\n", tc.id, tc.expect, got)
+		}
+	}
+
+	// raising the standard of living lowers the death rate enough to
+	// push the equilibrium past the next birth-rate band
+	low := wge.EquilibriumPopulation(10, 0.3, 10_000)
+	high := wge.EquilibriumPopulation(10, 0.7, 10_000)
+	if !(low < high) {
+		t.Errorf("equilibrium: expected %d < %d\n", low, high)
+	}
+
+	// verify that the colony is stable at the equilibrium
+	pop := wge.EquilibriumPopulation(10, 0.7, 10_000)
+	p := wge.NewCivilian(pop, 10)
+	if !(p.NaturalBirthRate(0.7, float64(pop)/10_000) <= p.NaturalDeathRate(0.7, float64(pop)/10_000)) {
+		t.Errorf("equilibrium: expected births <= deaths at %d\n", pop)
+	}
+}
+
+func TestEffectiveStandardOfLiving(t *testing.T) {
+	for _, tc := range []struct {
+		id      int
+		base    float64
+		taxRate float64
+		expect  float64
+	}{
+		{1, 1.0, 0, 1.0},
+		{2, 1.0, 0.2, 0.9},
+		{3, 1.0, 1.0, 0.5},
+		{4, 2.0, 0.5, 1.5},
+		{5, 1.0, -0.5, 1.0},
+		{6, 1.0, 2.0, 0.5},
+		{7, 0.01, 0.5, 0.01},
+		{8, 5.0, 0, 5.0},
+	} {
+		if got := wge.EffectiveStandardOfLiving(tc.base, tc.taxRate); !isClose(got, tc.expect) {
+			t.Errorf("effectiveStandardOfLiving: %d: expected %g, got %g\n", tc.id, tc.expect, got)
+		}
+	}
+	prior := wge.EffectiveStandardOfLiving(1.5, 0)
+	for rate := 0.1; rate <= 1.0; rate += 0.1 {
+		got := wge.EffectiveStandardOfLiving(1.5, rate)
+		if !(got < prior) {
+			t.Errorf("effectiveStandardOfLiving: %g: expected < %g, got %g\n", rate, prior, got)
+		}
+		prior = got
+	}
+}
+
+func TestSmoothStandard(t *testing.T) {
+	// no history and alpha 1 both return the raw value
+	if got := wge.SmoothStandard(nil, 2.0, 0.5); !isClose(2.0, got) {
+		t.Errorf("smooth: empty: expected 2, got %g\n", got)
+	}
+	if got := wge.SmoothStandard([]float64{1.0, 1.0}, 2.0, 1); !isClose(2.0, got) {
+		t.Errorf("smooth: alpha 1: expected 2, got %g\n", got)
+	}
+	// a step from 1.0 to 2.0 is followed gradually
+	history := []float64{1.0, 1.0, 1.0}
+	prev := 1.0
+	for turn, expect := range []float64{1.5, 1.75, 1.875, 1.9375} {
+		got := wge.SmoothStandard(history, 2.0, 0.5)
+		if !isClose(expect, got) {
+			t.Errorf("smooth: step: %d: expected %g, got %g\n", turn, expect, got)
+		} else if !(prev < got && got < 2.0) {
+			t.Errorf("smooth: step: %d: expected between %g and 2, got %g\n", turn, prev, got)
+		}
+		history, prev = append(history, 2.0), got
+	}
+}
+
+func TestBlendStandard(t *testing.T) {
+	// well fed but short of goods
+	const food, goods = 1.2, 0.4
+	for _, tc := range []struct {
+		id         int
+		foodWeight float64
+		expect     float64
+	}{
+		{1, 0.75, 1.0},
+		{2, 0.9, 1.12},
+		{3, 0.5, 0.8},
+		{4, 0, 0.4},
+		{5, 1, 1.2},
+		{6, 1.5, 1.2},
+	} {
+		if got := wge.BlendStandard(food, goods, tc.foodWeight); !isClose(tc.expect, got) {
+			t.Errorf("blend: %d: expected %g, got %g\n", tc.id, tc.expect, got)
+		}
+	}
+	// the result is clamped from below; the rate config limits the top
+	if got := wge.BlendStandard(0, 0, 0.75); !isClose(0.01, got) {
+		t.Errorf("blend: low: expected 0.01, got %g\n", got)
+	}
+	if got := wge.BlendStandard(10, 10, 0.75); !isClose(10.0, got) {
+		t.Errorf("blend: high: expected 10, got %g\n", got)
+	}
+}
@@ -0,0 +1,155 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// compile time checks that Professional implements the interfaces
+var (
+	_ PopulationGroup = Professional{}
+	_ TechLevel       = Professional{}
+	_ Unit            = Professional{}
+)
+
+// Professional is a population unit composed of doctors, engineers,
+// scientists, and other skilled workers. Professionals expect more from
+// the colony than civilians do (see Colony.PerceivedStandard).
+// They are always loyal and don't reproduce. For now they only live in
+// open colonies, so they never need life support.
+type Professional struct {
+	qty       int
+	techLevel int
+}
+
+// auxProfessional is a helper to convert to/from json.
+// used to implement json.Marshaler and json.Unmarshaler interfaces.
+type auxProfessional struct {
+	Professionals int `json:"professionals"`
+	TechLevel     int `json:"tech-level"`
+}
+
+// NewProfessional returns a unit of professionals at the tech level.
+func NewProfessional(pop, techLevel int) Professional {
+	var p Professional
+	p.qty = pop
+	p.techLevel = techLevel
+	return p
+}
+
+// Code implements the Unit interface.
+func (p Professional) Code() string {
+	return "PRO"
+}
+
+// Describe implements the Unit interface.
+func (p Professional) Describe() UnitDescription {
+	return describe(p)
+}
+
+// FoodNeeded implements the PopulationGroup interface.
+// Professionals eat the same as civilians at the same tech level.
+func (p Professional) FoodNeeded() float64 {
+	return float64(p.qty) * 0.01 * 0.0125 * techFoodFactor(p.techLevel)
+}
+
+// LifeSupportNeeded implements the PopulationGroup interface.
+// Professionals only live in open colonies, so it is always zero.
+func (p Professional) LifeSupportNeeded() float64 {
+	return 0
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (p Professional) MarshalJSON() ([]byte, error) {
+	var aux auxProfessional
+	aux.Professionals = p.qty
+	aux.TechLevel = p.techLevel
+	return json.Marshal(&aux)
+}
+
+// Mass implements the Unit interface.
+// The mass per unit is 1.00 at tech 5 and is scaled by tech level.
+func (p Professional) Mass() float64 {
+	const massPerUnit = 1.00 // per 100
+	return p.Quantity() * massPerUnit * techMassFactor(p.techLevel)
+}
+
+// NaturalDeathRate implements the PopulationGroup interface.
+func (p Professional) NaturalDeathRate(standardOfLiving, pctCapacity float64) float64 {
+	return naturalDeathRate(p.techLevel, standardOfLiving, pctCapacity)
+}
+
+// Population implements the PopulationGroup interface.
+func (p Professional) Population() int {
+	return p.qty
+}
+
+// Quantity implements the Unit interface.
+func (p Professional) Quantity() float64 {
+	// there are 100 professionals per population unit
+	return float64(p.qty) * 0.01
+}
+
+// Rebels implements the PopulationGroup interface.
+// Professionals are always loyal.
+func (p Professional) Rebels() int {
+	return 0
+}
+
+// TechLevel implements the TechLevel interface.
+func (p Professional) TechLevel() int {
+	return p.techLevel
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (p *Professional) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	var aux auxProfessional
+	if err := dec.Decode(&aux); err != nil {
+		return fmt.Errorf("decode professional: %w", err)
+	}
+
+	p.qty = aux.Professionals
+	p.techLevel = aux.TechLevel
+
+	if err := p.Validate(); err != nil {
+		return fmt.Errorf("decode professional: %w", err)
+	}
+
+	return nil
+}
+
+// Validate returns an error naming the first field that is out of range.
+func (p Professional) Validate() error {
+	if p.qty < 0 {
+		return fmt.Errorf("professionals: %d: must not be negative", p.qty)
+	} else if !(0 <= p.techLevel && p.techLevel <= 10) {
+		return fmt.Errorf("tech-level: %d: must be 0 to 10", p.techLevel)
+	}
+	return nil
+}
+
+// Volume implements the Unit interface.
+// The volume per unit is 1.00 at tech 5 and is scaled by tech level.
+func (p Professional) Volume() float64 {
+	const volumePerUnit = 1.00 // per 100
+	return p.Quantity() * volumePerUnit * techVolumeFactor(p.techLevel)
+}
@@ -0,0 +1,73 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestProfessionals(t *testing.T) {
+	var u wge.Unit = wge.NewProfessional(1_000, 6)
+	pg, ok := u.(wge.PopulationGroup)
+	if !ok {
+		t.Fatalf("professionals: expected PopulationGroup\n")
+	}
+	if pg.Population() != 1_000 || pg.Rebels() != 0 {
+		t.Errorf("professionals: expected 1000 loyal, got %d/%d\n", pg.Population(), pg.Rebels())
+	}
+	if got := u.Describe(); got.Code != "PRO" || !isClose(10, got.Quantity) {
+		t.Errorf("professionals: describe: expected PRO 10, got %s %g\n", got.Code, got.Quantity)
+	}
+	if _, ok := u.(wge.Reproducer); ok {
+		t.Errorf("professionals: expected not to be a Reproducer\n")
+	}
+	// professionals eat like civilians at the same tech level
+	if got, expect := pg.FoodNeeded(), wge.NewCivilian(1_000, 6).FoodNeeded(); !isClose(expect, got) {
+		t.Errorf("professionals: food: expected %8.4f, got %8.4f\n", expect, got)
+	}
+}
+
+func TestProfessionalsSave(t *testing.T) {
+	pro := wge.NewProfessional(1_000, 6)
+	// a colony holding professionals survives a trip through json
+	c := wge.NewColony(10_000, wge.NewCivilian(5_000, 5), pro)
+	var got wge.Colony
+	if data, err := json.Marshal(c); err != nil {
+		t.Errorf("professionals: colony: marshal: expected nil, got %v\n", err)
+	} else if err := json.Unmarshal(data, &got); err != nil {
+		t.Errorf("professionals: colony: unmarshal: expected nil, got %v\n", err)
+	} else if got.Checksum() != c.Checksum() {
+		t.Errorf("professionals: colony: expected %+v, got %+v\n", c.Members(), got.Members())
+	}
+	// and so does one in a file store
+	store := wge.NewFileStore(t.TempDir())
+	if err := store.Save("pro-1", pro); err != nil {
+		t.Errorf("professionals: store: save: expected nil, got %v\n", err)
+	} else if u, err := store.Load("pro-1"); err != nil {
+		t.Errorf("professionals: store: load: expected nil, got %v\n", err)
+	} else if u != wge.Unit(pro) {
+		t.Errorf("professionals: store: expected %+v, got %+v\n", pro, u)
+	}
+	// bad values are rejected
+	var bad wge.Professional
+	if err := json.Unmarshal([]byte(`{"professionals":100,"tech-level":11}`), &bad); err == nil {
+		t.Errorf("professionals: unmarshal: tech 11: expected error, got nil\n")
+	}
+}
@@ -0,0 +1,418 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge
+
+import "fmt"
+
+// RateConfig holds the tables used to calculate birth and death rates.
+// Use DefaultRateConfig to get the tables the engine uses.
+type RateConfig struct {
+	// DeathBase is the base death rate, indexed by tech level.
+	DeathBase [11]float64
+	// BirthStandard is applied to the birth rate based on standard of living.
+	BirthStandard RateBands
+	// BirthCapacity is applied to the birth rate based on percent capacity.
+	BirthCapacity RateBands
+	// DeathStandard is applied to the death rate based on standard of living.
+	DeathStandard RateBands
+	// DeathCapacity is applied to the death rate based on percent capacity.
+	DeathCapacity RateBands
+	// MergeMinRebels is the fewest loyal citizens that turn rebel when
+	// two units merge. Merging units always increases discontent.
+	MergeMinRebels int
+	// MergeTechPenalty is the fraction of a unit's rebels that recruit
+	// loyal citizens for each tech level the unit loses in a merge.
+	MergeTechPenalty float64
+	// MergeLossless waives MergeMinRebels when every unit in a merge has
+	// the same tech level and no rebels, so consolidating loyal colonies
+	// adds no discontent. It is off by default.
+	MergeLossless bool
+	// GraceTurns is the number of turns after a unit is founded during
+	// which overcrowding deaths are softened. Zero turns the grace off.
+	GraceTurns int
+	// StandardRange limits the standard of living used by both rates.
+	StandardRange RateRange
+	// BirthCapacityRange limits the percent capacity used by the birth rate.
+	BirthCapacityRange RateRange
+	// DeathCapacityRange limits the percent capacity used by the death rate.
+	// It must reach past 1.0 for the overcrowding bands to apply.
+	DeathCapacityRange RateRange
+}
+
+// RateRange is the range a value is clamped to before the bands are checked.
+// The zero RateRange, as in a RateConfig written as a literal or saved
+// before the ranges were added, means the range from DefaultRateConfig.
+type RateRange struct {
+	Min float64
+	Max float64
+}
+
+// RateBand is a multiplier that applies when a value is past the limit.
+type RateBand struct {
+	Limit      float64
+	Multiplier float64
+}
+
+// RateBands is a set of multipliers for ranges of a value.
+// The Below bands are checked first, in order, and the first band
+// where the value is less than the limit is used.
+// The Above bands are checked next, in order, and the first band
+// where the value is greater than the limit is used.
+// If no band matches, the Default multiplier is used.
+type RateBands struct {
+	Below   []RateBand
+	Above   []RateBand
+	Default float64
+}
+
+// DefaultRateConfig returns the tables used by the engine.
+// Each call returns a new copy, so callers may change it freely.
+func DefaultRateConfig() RateConfig {
+	return RateConfig{
+		DeathBase: [11]float64{
+			1_500.0 / 100_000.0,
+			1_400.0 / 100_000.0,
+			1_300.0 / 100_000.0,
+			1_200.0 / 100_000.0,
+			1_100.0 / 100_000.0,
+			1_000.0 / 100_000.0,
+			900.0 / 100_000.0,
+			800.0 / 100_000.0,
+			700.0 / 100_000.0,
+			600.0 / 100_000.0,
+			500.0 / 100_000.0,
+		},
+		BirthStandard: RateBands{
+			Below: []RateBand{
+				{0.25, 1.50},
+				{0.80, 1.25},
+				{1.20, 1.00}, // 80% to 120% is the standard range
+			},
+			Above: []RateBand{
+				{1.75, 0.50}, // must be checked before 1.20
+				{1.20, 0.75},
+			},
+			Default: 1.00,
+		},
+		BirthCapacity: RateBands{
+			Below: []RateBand{
+				{0.25, 1.25},
+				{0.40, 1.10},
+				{0.65, 1.00}, // 40% to 65% is the standard range
+				{0.70, 0.90},
+				{0.80, 0.60},
+				{0.90, 0.25},
+				{0.95, 0.10},
+			},
+			Default: 0.05,
+		},
+		DeathStandard: RateBands{
+			Above: []RateBand{
+				{1.500, 0.975},
+				{1.250, 0.950},
+				{0.990, 1.000}, // base rate
+				{0.875, 1.025},
+				{0.750, 1.050},
+				{0.625, 1.075},
+				{0.500, 1.100},
+				{0.375, 1.125},
+				{0.250, 1.150},
+				{0.125, 1.175},
+			},
+			Default: 1.00,
+		},
+		DeathCapacity: RateBands{
+			Above: []RateBand{
+				{4.000, 50.000}, // packed transports are lethal
+				{3.000, 20.000},
+				{2.250, 5.000},
+				{2.000, 3.000},
+				{1.500, 2.000},
+				{0.990, 1.500},
+				{0.975, 1.250},
+				{0.950, 1.100},
+				{0.925, 1.025},
+				{0.900, 1.010},
+			},
+			Default: 1.00,
+		},
+		MergeMinRebels:     1,
+		MergeTechPenalty:   0.01,
+		GraceTurns:         5,
+		StandardRange:      RateRange{0.01, 3.0},
+		BirthCapacityRange: RateRange{0.01, 1.0},
+		DeathCapacityRange: RateRange{0.01, 10.0},
+	}
+}
+
+// defaultRateConfig is used by the rate functions when no config is given.
+var defaultRateConfig = DefaultRateConfig()
+
+// grace returns the fraction of the overcrowding penalty forgiven for a
+// unit that is age turns old. It is zero once the unit is GraceTurns old.
+func (cfg RateConfig) grace(age int) float64 {
+	if age < 0 || age >= cfg.GraceTurns {
+		return 0
+	}
+	return 1 - float64(age)/float64(cfg.GraceTurns)
+}
+
+// mergeMinRebels returns the fewest loyal citizens that turn rebel in a
+// merge. It is zero for a lossless merge of units with the same tech level
+// and no rebels.
+func (cfg RateConfig) mergeMinRebels(sameTech, loyal bool) int {
+	if cfg.MergeLossless && sameTech && loyal {
+		return 0
+	}
+	return cfg.MergeMinRebels
+}
+
+// mergeDiscontent returns the loyal citizens recruited by the rebels of a
+// unit that loses deltaTech levels in a merge.
+func (cfg RateConfig) mergeDiscontent(rebels, deltaTech int) int {
+	if deltaTech <= 0 || cfg.MergeTechPenalty <= 0 {
+		return 0
+	}
+	return int(float64(rebels*deltaTech) * cfg.MergeTechPenalty)
+}
+
+// Multiplier returns the multiplier for the band that v falls in.
+func (rb RateBands) Multiplier(v float64) float64 {
+	for _, band := range rb.Below {
+		if v < band.Limit {
+			return band.Multiplier
+		}
+	}
+	for _, band := range rb.Above {
+		if v > band.Limit {
+			return band.Multiplier
+		}
+	}
+	return rb.Default
+}
+
+// clamp limits v to the range, using def if the range is zero.
+func (r RateRange) clamp(v float64, def RateRange) float64 {
+	if r == (RateRange{}) {
+		r = def
+	}
+	return clamp(v, r.Min, r.Max)
+}
+
+// RateBreakdown explains how the birth and death rates for a population
+// were calculated. It is a diagnostic for reports, not for the hot path.
+type RateBreakdown struct {
+	Birth RateDetail
+	Death RateDetail
+}
+
+// RateDetail is the calculation of a single rate. The final Rate is the
+// Base rate times each of the Multipliers, in order. When the final clamp
+// changes the rate, the adjustment is reported as the last multiplier.
+//
+// Factors explains the same calculation for players. It starts with the
+// base rate, labeled FactorTechBase, and lists only the multipliers that
+// changed the rate, each with a label saying what caused it.
+type RateDetail struct {
+	Base        float64
+	Multipliers []float64
+	Factors     []RateFactor
+	Rate        float64
+}
+
+// RateFactor is one labeled step in the calculation of a rate.
+// The first factor is the base rate; the rest are multipliers.
+type RateFactor struct {
+	Label string
+	Value float64
+}
+
+// Labels for the factors in a RateDetail.
+const (
+	FactorTechBase         = "tech base"
+	FactorStandardOfLiving = "standard of living"
+	FactorOvercrowding     = "overcrowding"
+	FactorLivingSpace      = "living space"
+	FactorResortBonus      = "resort bonus"
+	FactorLimit            = "limit"
+)
+
+// base records the base rate if rd is not nil.
+func (rd *RateDetail) base(rate float64) {
+	if rd != nil {
+		rd.Base = rate
+		rd.Factors = append(rd.Factors, RateFactor{Label: FactorTechBase, Value: rate})
+	}
+}
+
+// apply multiplies the rate and records the multiplier if rd is not nil.
+func (rd *RateDetail) apply(label string, rate, multiplier float64) float64 {
+	if rd != nil {
+		rd.Multipliers = append(rd.Multipliers, multiplier)
+		if multiplier != 1 {
+			rd.Factors = append(rd.Factors, RateFactor{Label: label, Value: multiplier})
+		}
+	}
+	return rate * multiplier
+}
+
+// clamp limits the final rate and records the adjustment if rd is not nil.
+func (rd *RateDetail) clamp(rate, lo, hi float64) float64 {
+	final := clamp(rate, lo, hi)
+	if rd != nil {
+		if final != rate && rate != 0 {
+			rd.Multipliers = append(rd.Multipliers, final/rate)
+			rd.Factors = append(rd.Factors, RateFactor{Label: FactorLimit, Value: final / rate})
+		}
+		rd.Rate = final
+	}
+	return final
+}
+
+// RateCall describes one calculation of a birth or death rate.
+// IsOnShip and IsResortColony are always false for death rates.
+type RateCall struct {
+	Birth            bool // true for a birth rate, false for a death rate
+	TechLevel        int
+	StandardOfLiving float64
+	PctCapacity      float64
+	IsOnShip         bool
+	IsResortColony   bool
+	Rate             float64
+}
+
+// rateObserver is called after each rate calculation when it is not nil.
+var rateObserver func(RateCall)
+
+// SetRateObserver sets a function that is called with the inputs and the
+// result of every BirthRateWith and DeathRateWith calculation, including
+// the ones made by ApplyTurn and the other turn functions. It returns the
+// previous observer so that callers can restore it. A nil observer, the
+// default, turns the calls off.
+//
+// The observer is a profiling tap. It is not safe to change it while
+// turns are running in other goroutines.
+func SetRateObserver(fn func(RateCall)) func(RateCall) {
+	previous := rateObserver
+	rateObserver = fn
+	return previous
+}
+
+// BirthRateWith calculates the birth rate for a population using the given tables.
+// The basic birth rate ranges from 0.25% to 10% of the population.
+// The variation depends on the standard of living as well as the
+// availability of "open" living space in the colony.
+func BirthRateWith(cfg RateConfig, techLevel int, standardOfLiving, pctCapacity float64, isOnShip, isResortColony bool) float64 {
+	rate := birthRate(cfg, techLevel, standardOfLiving, pctCapacity, isOnShip, isResortColony, nil)
+	if rateObserver != nil {
+		rateObserver(RateCall{Birth: true, TechLevel: techLevel, StandardOfLiving: standardOfLiving, PctCapacity: pctCapacity, IsOnShip: isOnShip, IsResortColony: isResortColony, Rate: rate})
+	}
+	return rate
+}
+
+// DeathRateWith calculates the basic death rate for a population using the given tables.
+// The rate is based on the tech level, standard of living, and
+// availability of living space in the colony or ship.
+//
+// Unlike births, percent capacity is allowed to go above 1.0 (up to 10.0
+// with the default DeathCapacityRange) so that the overcrowding bands can
+// punish overloaded ships and colonies.
+// With the default tables, deaths are multiplied by 3 above 200% capacity,
+// 5 above 225%, 20 above 300%, and 50 above 400%.
+func DeathRateWith(cfg RateConfig, techLevel int, standardOfLiving, pctCapacity float64) float64 {
+	rate := deathRate(cfg, techLevel, standardOfLiving, pctCapacity, 0, nil)
+	if rateObserver != nil {
+		rateObserver(RateCall{TechLevel: techLevel, StandardOfLiving: standardOfLiving, PctCapacity: pctCapacity, Rate: rate})
+	}
+	return rate
+}
+
+// DeathRateAt is DeathRateWith for a unit that is age turns old. Units
+// dumped into a crowded colony are spared most of the overcrowding deaths
+// while they settle in: at age 0 the overcrowding multiplier is forgiven
+// entirely, and the forgiveness falls in equal steps to nothing at
+// cfg.GraceTurns. With five turns, a unit that is two turns old suffers
+// 40% of the extra deaths from overcrowding. The rest of the rate is not
+// changed, and with no grace turns the rate is the same as DeathRateWith.
+func DeathRateAt(cfg RateConfig, techLevel int, standardOfLiving, pctCapacity float64, age int) float64 {
+	rate := deathRate(cfg, techLevel, standardOfLiving, pctCapacity, cfg.grace(age), nil)
+	if rateObserver != nil {
+		rateObserver(RateCall{TechLevel: techLevel, StandardOfLiving: standardOfLiving, PctCapacity: pctCapacity, Rate: rate})
+	}
+	return rate
+}
+
+// birthRate implements BirthRateWith, recording each step in rd if it is not nil.
+func birthRate(cfg RateConfig, techLevel int, standardOfLiving, pctCapacity float64, isOnShip, isResortColony bool, rd *RateDetail) float64 {
+	if isOnShip { // births never happen on a ship
+		return 0
+	}
+	// clamp the standard of living and percent capacity
+	standardOfLiving = cfg.StandardRange.clamp(standardOfLiving, defaultRateConfig.StandardRange)
+	pctCapacity = cfg.BirthCapacityRange.clamp(pctCapacity, defaultRateConfig.BirthCapacityRange)
+
+	// the base rate is determined by tech level
+	birthRate := clamp(float64(11-techLevel)*0.1, 0.0025, 0.10)
+	rd.base(birthRate)
+
+	// resort colonies increase the birth rate
+	if isResortColony {
+		birthRate = rd.apply(FactorResortBonus, birthRate, 2)
+	}
+
+	// standard of living influences it
+	birthRate = rd.apply(FactorStandardOfLiving, birthRate, cfg.BirthStandard.Multiplier(standardOfLiving))
+
+	// overcrowding reduces the birth rate, and open living space raises it
+	crowding, label := cfg.BirthCapacity.Multiplier(pctCapacity), FactorOvercrowding
+	if crowding > 1 {
+		label = FactorLivingSpace
+	}
+	birthRate = rd.apply(label, birthRate, crowding)
+
+	// birth rate is never less than 0.25% or higher than 10%
+	return rd.clamp(birthRate, 0.0025, 0.10)
+}
+
+// deathRate implements DeathRateWith, recording each step in rd if it is not nil.
+// Grace, from 0 to 1, is the fraction of the overcrowding penalty that is
+// forgiven; it is 0 except for newly founded units.
+func deathRate(cfg RateConfig, techLevel int, standardOfLiving, pctCapacity, grace float64, rd *RateDetail) float64 {
+	if !(0 <= techLevel && techLevel < len(cfg.DeathBase)) {
+		panic(fmt.Sprintf("assert(0 <= %d <= 10)", techLevel))
+	}
+	// clamp the standard of living and percent capacity
+	standardOfLiving = cfg.StandardRange.clamp(standardOfLiving, defaultRateConfig.StandardRange)
+	pctCapacity = cfg.DeathCapacityRange.clamp(pctCapacity, defaultRateConfig.DeathCapacityRange)
+
+	// the base rate is determined by tech level
+	deathRate := cfg.DeathBase[techLevel]
+	rd.base(deathRate)
+
+	// standard of living influences it
+	deathRate = rd.apply(FactorStandardOfLiving, deathRate, cfg.DeathStandard.Multiplier(standardOfLiving))
+
+	// overcrowding increases it, less so during the grace period
+	crowding := cfg.DeathCapacity.Multiplier(pctCapacity)
+	if grace > 0 && crowding > 1 {
+		crowding = 1 + (crowding-1)*(1-clamp(grace, 0, 1))
+	}
+	deathRate = rd.apply(FactorOvercrowding, deathRate, crowding)
+
+	// death rate is never less than 0.25% or higher than 75%
+	return rd.clamp(deathRate, 0.00_2500, 0.75_0000)
+}
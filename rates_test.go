@@ -0,0 +1,177 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge_test
+
+import (
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestRateConfig(t *testing.T) {
+	// verify that the default config reproduces the engine's rates
+	cfg := wge.DefaultRateConfig()
+	crowded := wge.DefaultRateConfig()
+	crowded.DeathCapacity.Above[3].Multiplier = 4.0 // above 200%
+	crowded.BirthCapacity.Default = 0.10
+	for _, tc := range []struct {
+		id        int
+		cfg       wge.RateConfig
+		techLevel int
+		sol, pct  float64
+		birth     float64
+		death     float64
+		natural   bool // the engine uses cfg, so NaturalBirthRate and NaturalDeathRate match
+	}{
+		{1, cfg, 5, 1.0, 0.5, 0.1000, 0.0100, true},
+		{2, cfg, 0, 0.2, 0.97, 0.0075, 0.0193875, true},
+		{3, cfg, 10, 2.0, 0.2, 0.0625, 0.004875, true},
+		{4, cfg, 8, 1.0, 2.1, 0.0050, 0.0210, true},
+		{5, crowded, 8, 1.0, 2.1, 0.0100, 0.0280, false},
+	} {
+		if got := wge.BirthRateWith(tc.cfg, tc.techLevel, tc.sol, tc.pct, false, false); !isClose(tc.birth, got) {
+			t.Errorf("birthRateWith: %d: expected %8.4f%%, got %8.4f%%\n", tc.id, 100*tc.birth, 100*got)
+		}
+		if got := wge.DeathRateWith(tc.cfg, tc.techLevel, tc.sol, tc.pct); !isClose(tc.death, got) {
+			t.Errorf("deathRateWith: %d: expected %8.4f%%, got %8.4f%%\n", tc.id, 100*tc.death, 100*got)
+		}
+		if !tc.natural {
+			continue
+		}
+		p := wge.NewCivilian(1000, tc.techLevel)
+		if got := p.NaturalBirthRate(tc.sol, tc.pct); !isClose(tc.birth, got) {
+			t.Errorf("naturalBirthRate: %d: expected %8.4f%%, got %8.4f%%\n", tc.id, 100*tc.birth, 100*got)
+		}
+		if got := p.NaturalDeathRate(tc.sol, tc.pct); !isClose(tc.death, got) {
+			t.Errorf("naturalDeathRate: %d: expected %8.4f%%, got %8.4f%%\n", tc.id, 100*tc.death, 100*got)
+		}
+	}
+
+	// verify that a tuned config changes the rates
+	tuned := wge.DefaultRateConfig()
+	tuned.DeathBase[10] = 1_000.0 / 100_000.0
+	tuned.BirthStandard.Below[2].Multiplier = 0.5
+	if got := wge.DeathRateWith(tuned, 10, 1, 0.5); !isClose(0.01, got) {
+		t.Errorf("deathRateWith: tuned: expected %8.4f%%, got %8.4f%%\n", 1.0, 100*got)
+	}
+	if got := wge.BirthRateWith(tuned, 1, 1, 0.6, false, false); !isClose(0.05, got) {
+		t.Errorf("birthRateWith: tuned: expected %8.4f%%, got %8.4f%%\n", 5.0, 100*got)
+	}
+	// and that tuning a copy does not change the defaults
+	if got := wge.DeathRateWith(wge.DefaultRateConfig(), 10, 1, 0.5); !isClose(0.005, got) {
+		t.Errorf("deathRateWith: default: expected %8.4f%%, got %8.4f%%\n", 0.5, 100*got)
+	}
+}
+
+func TestSetRateObserver(t *testing.T) {
+	units := []wge.Civilian{
+		wge.NewCivilian(1_000, 5),
+		wge.NewCivilian(2_000, 5),
+		wge.NewCivilian(3_000, 5),
+		wge.NewCivilian(1_000, 3),
+		wge.NewCivilian(2_000, 3),
+	}
+	var births, deaths int
+	previous := wge.SetRateObserver(func(call wge.RateCall) {
+		if call.Birth {
+			births++
+		} else {
+			deaths++
+		}
+		if call.StandardOfLiving != 1.0 || call.PctCapacity != 0.5 {
+			t.Errorf("observer: expected 1/0.5, got %g/%g\n", call.StandardOfLiving, call.PctCapacity)
+		}
+	})
+	defer wge.SetRateObserver(previous)
+	// the batch computes rates once per tech level
+	wge.ApplyTurnBatch(units, 1.0, 0.5)
+	if births != 2 || deaths != 2 {
+		t.Errorf("observer: batch: expected 2 births and 2 deaths, got %d and %d\n", births, deaths)
+	}
+	// each unit computes its own rates
+	for _, p := range units {
+		p.ApplyTurn(1.0, 0.5)
+	}
+	if births != 7 || deaths != 7 {
+		t.Errorf("observer: turn: expected 7 births and 7 deaths, got %d and %d\n", births, deaths)
+	}
+	// a nil observer isn't called
+	wge.SetRateObserver(nil)
+	wge.ApplyTurnBatch(units, 1.0, 0.5)
+	if births != 7 || deaths != 7 {
+		t.Errorf("observer: nil: expected no calls, got %d and %d\n", births-7, deaths-7)
+	}
+}
+
+func TestRateConfigRanges(t *testing.T) {
+	// a utopian band that the default range can never reach
+	cfg := wge.DefaultRateConfig()
+	cfg.BirthStandard.Above = append([]wge.RateBand{{4.0, 0.25}}, cfg.BirthStandard.Above...)
+	if got := wge.BirthRateWith(cfg, 5, 4.5, 0.5, false, false); !isClose(0.05, got) {
+		t.Errorf("ranges: default: expected %8.4f%%, got %8.4f%%\n", 5.0, 100*got)
+	}
+	cfg.StandardRange.Max = 5.0
+	if got := wge.BirthRateWith(cfg, 5, 4.5, 0.5, false, false); !isClose(0.025, got) {
+		t.Errorf("ranges: wide: expected %8.4f%%, got %8.4f%%\n", 2.5, 100*got)
+	}
+	// values past the new limit are still clamped
+	if got := wge.BirthRateWith(cfg, 5, 9.0, 0.5, false, false); !isClose(0.025, got) {
+		t.Errorf("ranges: past: expected %8.4f%%, got %8.4f%%\n", 2.5, 100*got)
+	}
+	// the overcrowding bands still see capacity above 1.0
+	if got := wge.DeathRateWith(cfg, 10, 1.0, 2.1); !isClose(0.015, got) {
+		t.Errorf("ranges: crowded: expected %8.4f%%, got %8.4f%%\n", 1.5, 100*got)
+	}
+	// a utopian colony's standard reaches the wider range
+	if got := wge.BirthRateWith(cfg, 5, wge.BlendStandard(4.5, 4.5, 0.75), 0.5, false, false); !isClose(0.025, got) {
+		t.Errorf("ranges: blend: expected %8.4f%%, got %8.4f%%\n", 2.5, 100*got)
+	}
+	// a zero range means the default range, not a clamp to 0
+	cfg.StandardRange, cfg.BirthCapacityRange, cfg.DeathCapacityRange = wge.RateRange{}, wge.RateRange{}, wge.RateRange{}
+	if got := wge.BirthRateWith(cfg, 5, 4.5, 0.5, false, false); !isClose(0.05, got) {
+		t.Errorf("ranges: zero: expected %8.4f%%, got %8.4f%%\n", 5.0, 100*got)
+	}
+	if got := wge.DeathRateWith(cfg, 10, 1.0, 2.1); !isClose(0.015, got) {
+		t.Errorf("ranges: zero: crowded: expected %8.4f%%, got %8.4f%%\n", 1.5, 100*got)
+	}
+}
+
+func TestDeathRateAt(t *testing.T) {
+	// at 210% capacity the crowding bands triple the tech 10 death rate
+	long := wge.DefaultRateConfig()
+	long.GraceTurns = 10
+	none := wge.DefaultRateConfig()
+	none.GraceTurns = 0
+	for _, tc := range []struct {
+		id     int
+		cfg    wge.RateConfig
+		age    int
+		expect float64
+	}{
+		{1, wge.DefaultRateConfig(), 0, 0.0050},
+		{2, wge.DefaultRateConfig(), 2, 0.0090},
+		{3, wge.DefaultRateConfig(), 5, 0.0150},
+		{4, wge.DefaultRateConfig(), -1, 0.0150},
+		{5, long, 5, 0.0100},
+		{6, long, 10, 0.0150},
+		{7, none, 0, 0.0150},
+	} {
+		if got := wge.DeathRateAt(tc.cfg, 10, 1.0, 2.1, tc.age); !isClose(tc.expect, got) {
+			t.Errorf("deathRateAt: %d: expected %8.4f%%, got %8.4f%%\n", tc.id, 100*tc.expect, 100*got)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge
+
+// Rng is a deterministic source of random numbers.
+// Two generators created from the same seed return the same sequence,
+// which is what keeps multiplayer clients in step.
+type Rng interface {
+	// Float64 returns a number in the range [0, 1).
+	Float64() float64
+	// Uint64 returns the next 64 bits of the sequence.
+	Uint64() uint64
+}
+
+// NewRng returns a splitmix64 generator started from the seed.
+func NewRng(seed uint64) Rng {
+	return &splitMix64{state: seed}
+}
+
+// ColonyRng returns a generator for one colony, derived from the base seed
+// of the game and the id of the colony. The same seed and id always give
+// the same sequence, while different ids give independent sequences, so
+// colonies can draw random numbers in any order, or in parallel, without
+// sharing a generator.
+//
+// The id and the seed are each scrambled with the splitmix64 output
+// function before they are combined, so nearby ids and seeds don't start
+// nearby streams.
+func ColonyRng(baseSeed uint64, colonyID uint64) Rng {
+	id := (&splitMix64{state: colonyID}).Uint64()
+	seed := (&splitMix64{state: baseSeed ^ id}).Uint64()
+	return &splitMix64{state: seed}
+}
+
+// splitMix64 is a small, fast generator with a 64-bit state.
+// Copying the struct forks the sequence.
+type splitMix64 struct {
+	state uint64
+}
+
+// Float64 implements the Rng interface.
+func (r *splitMix64) Float64() float64 {
+	return float64(r.Uint64()>>11) / (1 << 53)
+}
+
+// Uint64 implements the Rng interface.
+func (r *splitMix64) Uint64() uint64 {
+	r.state += 0x9e3779b97f4a7c15
+	z := r.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
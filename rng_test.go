@@ -0,0 +1,60 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge_test
+
+import (
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestColonyRng(t *testing.T) {
+	const seed = 42
+	draw := func(r wge.Rng) []uint64 {
+		var list []uint64
+		for i := 0; i < 8; i++ {
+			list = append(list, r.Uint64())
+		}
+		return list
+	}
+	same := func(a, b []uint64) int {
+		n := 0
+		for i := range a {
+			if a[i] == b[i] {
+				n++
+			}
+		}
+		return n
+	}
+	first, again := draw(wge.ColonyRng(seed, 1)), draw(wge.ColonyRng(seed, 1))
+	if n := same(first, again); n != len(first) {
+		t.Errorf("colonyRng: repeat: expected %d matches, got %d\n", len(first), n)
+	}
+	for _, tc := range []struct {
+		id           int
+		seed, colony uint64
+	}{
+		{1, seed, 2},
+		{2, seed, 0},
+		{3, seed + 1, 1},
+		{4, seed ^ 1, 0},
+	} {
+		if n := same(first, draw(wge.ColonyRng(tc.seed, tc.colony))); n != 0 {
+			t.Errorf("colonyRng: %d: expected no matches, got %d\n", tc.id, n)
+		}
+	}
+}
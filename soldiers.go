@@ -0,0 +1,252 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// compile time checks that Soldier implements the interfaces
+var (
+	_ PopulationGroup = Soldier{}
+	_ TechLevel       = Soldier{}
+	_ Unit            = Soldier{}
+)
+
+// Soldier is a population unit composed of the armed forces.
+// Soldiers are always loyal and don't reproduce.
+type Soldier struct {
+	qty       int
+	techLevel int
+	kind      ColonyKind
+	env       Environment
+	onShip    bool
+}
+
+// auxSoldier is a helper to convert to/from json.
+// used to implement json.Marshaler and json.Unmarshaler interfaces.
+type auxSoldier struct {
+	Soldiers    int         `json:"soldiers"`
+	TechLevel   int         `json:"tech-level"`
+	ColonyKind  ColonyKind  `json:"colony-kind,omitempty"`
+	Environment Environment `json:"environment,omitempty"`
+	OnShip      bool        `json:"on-ship,omitempty"`
+}
+
+func NewSoldier(pop, techLevel int) Soldier {
+	var s Soldier
+	s.qty = pop
+	s.techLevel = techLevel
+	return s
+}
+
+// Clone returns a deep copy of the unit.
+func (s Soldier) Clone() Soldier {
+	return s
+}
+
+// Code implements the Unit interface.
+func (s Soldier) Code() string {
+	return "SLD"
+}
+
+// Demobilize returns the soldiers as a unit of loyal civilians with the
+// same tech level and location. No one is lost when a unit stands down,
+// so the civilians number the same as the soldiers. Soldiers don't keep
+// a founding turn, so the civilians are founded on turn 0.
+func (s Soldier) Demobilize() Civilian {
+	p := NewCivilian(s.qty, s.techLevel)
+	p.kind, p.env, p.onShip = s.kind, s.env, s.onShip
+	return p
+}
+
+// Describe implements the Unit interface.
+func (s Soldier) Describe() UnitDescription {
+	return describe(s)
+}
+
+// Equal returns true if the two units have the same state.
+func (s Soldier) Equal(t Soldier) bool {
+	return s == t
+}
+
+// FoodNeeded implements the PopulationGroup interface.
+// Soldiers eat the same as civilians at the same tech level.
+func (s Soldier) FoodNeeded() float64 {
+	return float64(s.qty) * 0.01 * 0.0125 * techFoodFactor(s.techLevel)
+}
+
+// IsOnLifeSupport returns true if the population depends on life support for survival.
+// This is true for all ships and closed colonies.
+func (s Soldier) IsOnLifeSupport() bool {
+	return s.onShip || s.kind == ClosedColony
+}
+
+// IsOnShip returns true if the population is on a ship.
+func (s Soldier) IsOnShip() bool {
+	return s.onShip
+}
+
+// LifeSupportNeeded implements the PopulationGroup interface.
+// Soldiers need the same as civilians at the same tech level.
+func (s Soldier) LifeSupportNeeded() float64 {
+	if !s.IsOnLifeSupport() {
+		return 0
+	}
+	return float64(s.qty) * 0.01 * 0.5 * techLifeSupportFactor(s.techLevel) * lifeSupportFactor(s.onShip, s.env)
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (s Soldier) MarshalJSON() ([]byte, error) {
+	var aux auxSoldier
+	aux.Soldiers = s.qty
+	aux.TechLevel = s.techLevel
+	aux.ColonyKind = s.kind
+	aux.Environment = s.env
+	aux.OnShip = s.onShip
+	return json.Marshal(&aux)
+}
+
+// Mass implements the Unit interface.
+// Soldiers carry their equipment, so the mass per unit is 2.00 at tech 5.
+func (s Soldier) Mass() float64 {
+	const massPerUnit = 2.00 // per 100
+	return s.Quantity() * massPerUnit * techMassFactor(s.techLevel)
+}
+
+// Merge combines two soldier units.
+// The tech level is the weighted average of the units. Soldiers follow
+// orders, so merging never creates rebels.
+func (s Soldier) Merge(t Soldier) Soldier {
+	if s.qty == 0 {
+		return t
+	} else if t.qty == 0 {
+		return s
+	}
+	n := s
+	n.qty = s.qty + t.qty
+	n.techLevel = (s.qty*s.techLevel + t.qty*t.techLevel) / n.qty
+	return n
+}
+
+// NaturalDeathRate implements the PopulationGroup interface.
+func (s Soldier) NaturalDeathRate(standardOfLiving, pctCapacity float64) float64 {
+	return naturalDeathRate(s.techLevel, standardOfLiving, pctCapacity)
+}
+
+// Population implements the PopulationGroup interface.
+func (s Soldier) Population() int {
+	return s.qty
+}
+
+// Population64 returns the population as an int64 so that totals across
+// many large units can't overflow.
+func (s Soldier) Population64() int64 {
+	return int64(s.qty)
+}
+
+// Quantity implements the Unit interface.
+func (s Soldier) Quantity() float64 {
+	// there are 100 soldiers per population unit
+	return float64(s.qty) * 0.01
+}
+
+// Rebels implements the PopulationGroup interface.
+// Soldiers are always loyal.
+func (s Soldier) Rebels() int {
+	return 0
+}
+
+// Split returns the unit after qty soldiers leave, along with a new unit
+// holding the soldiers who left. It returns an error if qty is negative
+// or more than the number of soldiers.
+func (s Soldier) Split(qty int) (Soldier, Soldier, error) {
+	if qty < 0 || qty > s.qty {
+		return s, Soldier{}, fmt.Errorf("split soldier: %d: must be 0 to %d", qty, s.qty)
+	}
+	part := s
+	s.qty, part.qty = s.qty-qty, qty
+	return s, part, nil
+}
+
+// TechLevel implements the TechLevel interface.
+func (s Soldier) TechLevel() int {
+	return s.techLevel
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (s *Soldier) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	var aux auxSoldier
+	if err := dec.Decode(&aux); err != nil {
+		return fmt.Errorf("decode soldier: %w", err)
+	}
+
+	s.qty = aux.Soldiers
+	s.techLevel = aux.TechLevel
+	s.kind = aux.ColonyKind
+	s.env = aux.Environment
+	s.onShip = aux.OnShip
+
+	if err := s.Validate(); err != nil {
+		return fmt.Errorf("decode soldier: %w", err)
+	}
+
+	return nil
+}
+
+// Validate returns an error naming the first field that is out of range.
+func (s Soldier) Validate() error {
+	if s.qty < 0 {
+		return fmt.Errorf("soldiers: %d: must not be negative", s.qty)
+	} else if !(0 <= s.techLevel && s.techLevel <= 10) {
+		return fmt.Errorf("tech-level: %d: must be 0 to 10", s.techLevel)
+	} else if _, err := s.kind.MarshalText(); err != nil {
+		return fmt.Errorf("colony-kind: %w", err)
+	} else if _, err := s.env.MarshalText(); err != nil {
+		return fmt.Errorf("environment: %w", err)
+	}
+	return nil
+}
+
+// Volume implements the Unit interface.
+// Soldiers carry their equipment, so the volume per unit is 2.00 at tech 5.
+func (s Soldier) Volume() float64 {
+	const volumePerUnit = 2.00 // per 100
+	return s.Quantity() * volumePerUnit * techVolumeFactor(s.techLevel)
+}
+
+// WithColonyKind returns a copy of the unit stationed in the given kind of colony.
+func (s Soldier) WithColonyKind(kind ColonyKind) Soldier {
+	s.kind = kind
+	return s
+}
+
+// WithEnvironment returns a copy of the unit on a planet with the given environment.
+func (s Soldier) WithEnvironment(env Environment) Soldier {
+	s.env = env
+	return s
+}
+
+// WithShip returns a copy of the unit that is (or is not) on a ship.
+func (s Soldier) WithShip(onShip bool) Soldier {
+	s.onShip = onShip
+	return s
+}
@@ -0,0 +1,83 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge_test
+
+import (
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestReproducer(t *testing.T) {
+	// verify that only civilians reproduce
+	for _, tc := range []struct {
+		id     int
+		unit   wge.Unit
+		expect bool
+	}{
+		{1, wge.NewCivilian(1_000, 5), true},
+		{2, wge.NewSoldier(1_000, 5), false},
+	} {
+		_, ok := tc.unit.(wge.Reproducer)
+		if tc.expect != ok {
+			t.Errorf("reproducer: %d: %s: expected %v, got %v\n", tc.id, tc.unit.Code(), tc.expect, ok)
+		}
+		// both are population groups
+		if _, ok := tc.unit.(wge.PopulationGroup); !ok {
+			t.Errorf("reproducer: %d: %s: expected PopulationGroup\n", tc.id, tc.unit.Code())
+		}
+	}
+}
+
+func TestSoldiers(t *testing.T) {
+	p := wge.NewSoldier(300, 2).Merge(wge.NewSoldier(100, 6))
+	if p.Population() != 400 || p.TechLevel() != 3 || p.Rebels() != 0 {
+		t.Errorf("merge: expected 400/3/0, got %d/%d/%d\n", p.Population(), p.TechLevel(), p.Rebels())
+	}
+	if got := wge.NewSoldier(1_000, 5).LifeSupportNeeded(); got != 0 {
+		t.Errorf("lifeSupportNeeded: expected %8.4f, got %8.4f\n", 0.0, got)
+	}
+	if got := wge.NewSoldier(1_000, 5).WithShip(true).LifeSupportNeeded(); !isClose(5, got) {
+		t.Errorf("lifeSupportNeeded: ship: expected %8.4f, got %8.4f\n", 5.0, got)
+	}
+}
+
+func TestSoldierDemobilize(t *testing.T) {
+	sld := wge.NewSoldier(1_500, 6)
+	civ := sld.Demobilize()
+	if got := civ.Population(); got != 1_500 {
+		t.Errorf("demobilize: population: expected 1500, got %d\n", got)
+	}
+	if got := civ.Rebels(); got != 0 {
+		t.Errorf("demobilize: rebels: expected 0, got %d\n", got)
+	}
+	if got := civ.TechLevel(); got != 6 {
+		t.Errorf("demobilize: tech: expected 6, got %d\n", got)
+	}
+	// standing down and drafting again conserves everyone; the only
+	// change is the few who turn rebel when they are drafted
+	rest, again, err := civ.Conscript(1_000)
+	if err != nil {
+		t.Fatalf("demobilize: conscript: expected no error, got %v\n", err)
+	}
+	if got := rest.Population() + again.Population(); got != 1_500 {
+		t.Errorf("demobilize: round trip: expected 1500, got %d\n", got)
+	}
+	if got := again.Demobilize().Population(); got != 1_000 {
+		t.Errorf("demobilize: again: expected 1000, got %d\n", got)
+	}
+}
@@ -0,0 +1,138 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// compile time checks that the stores implement the interface
+var (
+	_ UnitStore = (*FileStore)(nil)
+	_ UnitStore = (*MemoryStore)(nil)
+)
+
+// UnitStore defines the interface for saving and loading units by id.
+// Simulation code should depend on this interface rather than on a
+// particular kind of storage.
+type UnitStore interface {
+	// Save stores the unit under the id, replacing any unit already there.
+	Save(id string, u Unit) error
+	// Load returns the unit stored under the id.
+	// If there is no such unit, the error wraps fs.ErrNotExist.
+	Load(id string) (Unit, error)
+}
+
+// FileStore is a UnitStore that keeps each unit in its own json file.
+// The file is named after the id and holds the code and the unit,
+// in the same format as DecodeUnits.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore returns a store that keeps units in the directory.
+// The directory must already exist.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// Load implements the UnitStore interface.
+func (s *FileStore) Load(id string) (Unit, error) {
+	name, err := s.path(id)
+	if err != nil {
+		return nil, fmt.Errorf("load unit: %w", err)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("load unit: %w", err)
+	}
+	u, err := decodeUnit(data)
+	if err != nil {
+		return nil, fmt.Errorf("load unit: %s: %w", id, err)
+	}
+	return u, nil
+}
+
+// Save implements the UnitStore interface.
+// The unit is written to a temporary file that is then renamed,
+// so a failed save never leaves a partial file behind.
+func (s *FileStore) Save(id string, u Unit) error {
+	name, err := s.path(id)
+	if err != nil {
+		return fmt.Errorf("save unit: %w", err)
+	}
+	data, err := json.Marshal(u)
+	if err != nil {
+		return fmt.Errorf("save unit: %s: %w", id, err)
+	}
+	data, err = json.Marshal(auxUnit{Code: u.Code(), Unit: data})
+	if err != nil {
+		return fmt.Errorf("save unit: %s: %w", id, err)
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("save unit: %w", err)
+	} else if err := os.Rename(tmp, name); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("save unit: %w", err)
+	}
+	return nil
+}
+
+// path returns the name of the file for the id.
+// Ids must be usable as file names, so path separators are not allowed.
+func (s *FileStore) path(id string) (string, error) {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) {
+		return "", fmt.Errorf("%q: invalid id", id)
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+// MemoryStore is a UnitStore that keeps units in memory.
+// It is not safe for concurrent use.
+type MemoryStore struct {
+	units map[string]Unit
+}
+
+// NewMemoryStore returns an empty store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{units: make(map[string]Unit)}
+}
+
+// Load implements the UnitStore interface.
+func (s *MemoryStore) Load(id string) (Unit, error) {
+	u, ok := s.units[id]
+	if !ok {
+		return nil, fmt.Errorf("load unit: %s: %w", id, fs.ErrNotExist)
+	}
+	return u, nil
+}
+
+// Save implements the UnitStore interface.
+// Units are values, so later changes by the caller don't affect the store.
+func (s *MemoryStore) Save(id string, u Unit) error {
+	if u == nil {
+		return fmt.Errorf("save unit: %s: nil unit", id)
+	}
+	s.units[id] = u
+	return nil
+}
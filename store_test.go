@@ -0,0 +1,65 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestUnitStore(t *testing.T) {
+	rebels, _ := wge.NewCivilianBuilder().Loyal(900).Rebel(100).Tech(4).Build()
+	units := map[string]wge.Unit{
+		"civ-1": wge.NewCivilian(1_000, 5),
+		"civ-2": rebels.ApplyTurn(1.0, 0.5),
+		"sld-1": wge.NewSoldier(400, 6).WithShip(true),
+	}
+	for _, tc := range []struct {
+		name  string
+		store wge.UnitStore
+	}{
+		{"file", wge.NewFileStore(t.TempDir())},
+		{"memory", wge.NewMemoryStore()},
+	} {
+		for id, u := range units {
+			if err := tc.store.Save(id, u); err != nil {
+				t.Fatalf("%s: save: %s: expected nil, got %v\n", tc.name, id, err)
+			}
+		}
+		for id, expect := range units {
+			got, err := tc.store.Load(id)
+			if err != nil {
+				t.Errorf("%s: load: %s: expected nil, got %v\n", tc.name, id, err)
+			} else if got != expect {
+				t.Errorf("%s: load: %s: expected %+v, got %+v\n", tc.name, id, expect, got)
+			}
+		}
+		if _, err := tc.store.Load("missing"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s: load: missing: expected fs.ErrNotExist, got %v\n", tc.name, err)
+		}
+	}
+	// ids are file names, so they can't escape the directory
+	fileStore := wge.NewFileStore(t.TempDir())
+	for _, id := range []string{"", "..", "../civ-1", "a/b"} {
+		if err := fileStore.Save(id, wge.NewCivilian(1, 5)); err == nil {
+			t.Errorf("file: save: %q: expected error, got nil\n", id)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+)
+
+// System is a star system containing colonies.
+type System struct {
+	colonies []Colony
+}
+
+// NewSystem returns a system with the given colonies.
+func NewSystem(colonies ...Colony) System {
+	return System{
+		colonies: append([]Colony(nil), colonies...),
+	}
+}
+
+// GalaxyChecksum returns a 64-bit hash of every system in the galaxy for
+// verifying saves. It combines the Checksum of each system in order.
+func GalaxyChecksum(systems ...System) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, s := range systems {
+		binary.LittleEndian.PutUint64(buf[:], s.Checksum())
+		_, _ = h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// Checksum returns a 64-bit hash of the system for verifying saves.
+// It combines the Checksum of each colony in the order the colonies are
+// stored, since that order identifies them. Any change to a member of
+// any colony, even a single rebel, changes the result.
+func (s System) Checksum() uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, c := range s.colonies {
+		binary.LittleEndian.PutUint64(buf[:], c.Checksum())
+		_, _ = h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// Colonies returns a copy of the colonies in the system.
+func (s System) Colonies() []Colony {
+	return append([]Colony(nil), s.colonies...)
+}
+
+// Population64 returns the total population of every colony in the system.
+// The total is accumulated in 64 bits, so it doesn't wrap around for
+// galaxy-spanning empires.
+func (s System) Population64() int64 {
+	var pop int64
+	for _, c := range s.colonies {
+		pop += c.Population64()
+	}
+	return pop
+}
+
+// TechHistogram returns the total population at each tech level across all
+// colonies in the system. Only units that have both a population and a tech
+// level are counted.
+func (s System) TechHistogram() map[int]int {
+	histogram := map[int]int{}
+	for _, c := range s.colonies {
+		for _, u := range c.members {
+			pg, ok := u.(PopulationGroup)
+			if !ok {
+				continue
+			}
+			tl, ok := u.(TechLevel)
+			if !ok {
+				continue
+			}
+			histogram[tl.TechLevel()] += pg.Population()
+		}
+	}
+	return histogram
+}
+
+// Validate checks every colony in the system and returns all of the
+// problems found, or nil if there are none. It runs Validate on each
+// member that has one and checks that no colony's capacity is over its
+// hard cap. The carrying capacity of a planet is applied to each colony
+// on it, so no two colonies may be placed on the same planet id.
+// Each error names the colony and member by their index in the system.
+func (s System) Validate() []error {
+	var errs []error
+	placed := map[string]int{} // planet id to the first colony on it
+	for i, c := range s.colonies {
+		if c.planet != "" {
+			if first, ok := placed[c.planet]; ok {
+				errs = append(errs, fmt.Errorf("colony %d: planet: %q: duplicate id, already used by colony %d", i, c.planet, first))
+			} else {
+				placed[c.planet] = i
+			}
+		}
+		if c.capacity < 0 {
+			errs = append(errs, fmt.Errorf("colony %d: capacity: %d: must not be negative", i, c.capacity))
+		}
+		if c.capacityLimit > 0 && c.capacity > c.capacityLimit {
+			errs = append(errs, fmt.Errorf("colony %d: capacity: %d: must not exceed limit %d", i, c.capacity, c.capacityLimit))
+		}
+		for j, u := range c.members {
+			v, ok := u.(interface{ Validate() error })
+			if !ok {
+				continue
+			}
+			if err := v.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("colony %d: member %d: %s: %w", i, j, u.Code(), err))
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,144 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestSystemTechHistogram(t *testing.T) {
+	s := wge.NewSystem(
+		wge.NewColony(100_000, wge.NewCivilian(10_000, 2), wge.NewSoldier(500, 2)),
+		wge.NewColony(100_000, wge.NewCivilian(7_000, 5), wge.NewCivilian(3_000, 2)),
+		wge.NewColony(100_000, crate{qty: 10}),
+	)
+	got := s.TechHistogram()
+	expect := map[int]int{2: 13_500, 5: 7_000}
+	if len(got) != len(expect) {
+		t.Errorf("histogram: expected %v, got %v\n", expect, got)
+	}
+	for tech, pop := range expect {
+		if got[tech] != pop {
+			t.Errorf("histogram: tech %d: expected %d, got %d\n", tech, pop, got[tech])
+		}
+	}
+}
+
+func TestSystemPopulation64(t *testing.T) {
+	const big = 2_000_000_000 // fits in 32 bits, but three of them don't
+	c := wge.NewColony(big, wge.NewCivilian(big, 5), wge.NewCivilian(big, 6), wge.NewSoldier(big, 5))
+	s := wge.NewSystem(c, c, c)
+	if got := c.Population64(); got != 3*big {
+		t.Errorf("population64: colony: expected %d, got %d\n", int64(3*big), got)
+	}
+	if got := s.Population64(); got != 9*big {
+		t.Errorf("population64: system: expected %d, got %d\n", int64(9*big), got)
+	}
+	p, _ := wge.NewCivilianBuilder().Loyal(big).Rebel(big).Tech(5).Build()
+	if got := p.Population64(); got != 2*big {
+		t.Errorf("population64: civilian: expected %d, got %d\n", int64(2*big), got)
+	}
+	if got := p.Quantity(); got != 2*big*0.01 {
+		t.Errorf("population64: quantity: expected %g, got %g\n", 2*big*0.01, got)
+	}
+}
+
+func TestSystemChecksum(t *testing.T) {
+	rebels, _ := wge.NewCivilianBuilder().Loyal(9_000).Rebel(1_000).Tech(4).Build()
+	colonies := []wge.Colony{
+		wge.NewColony(100_000, wge.NewCivilian(10_000, 2), wge.NewSoldier(500, 2), wge.NewSoldier(200, 6)),
+		wge.NewColony(50_000, rebels, wge.NewCivilian(3_000, 5)),
+	}
+	s := wge.NewSystem(colonies...)
+	// save then load every colony
+	var loaded []wge.Colony
+	for i, c := range colonies {
+		data, err := json.Marshal(c)
+		if err != nil {
+			t.Fatalf("checksum: %d: marshal: expected nil, got %v\n", i, err)
+		}
+		var got wge.Colony
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("checksum: %d: unmarshal: expected nil, got %v\n", i, err)
+		}
+		loaded = append(loaded, got)
+	}
+	if expect, got := s.Checksum(), wge.NewSystem(loaded...).Checksum(); expect != got {
+		t.Errorf("checksum: load: expected %x, got %x\n", expect, got)
+	}
+	// the order of the members doesn't matter
+	reordered := wge.NewColony(100_000, wge.NewSoldier(200, 6), wge.NewCivilian(10_000, 2), wge.NewSoldier(500, 2))
+	if expect, got := colonies[0].Checksum(), reordered.Checksum(); expect != got {
+		t.Errorf("checksum: reordered: expected %x, got %x\n", expect, got)
+	}
+	// a single rebel anywhere changes the checksum
+	oneMore, _ := wge.NewCivilianBuilder().Loyal(8_999).Rebel(1_001).Tech(4).Build()
+	changed := wge.NewSystem(colonies[0], wge.NewColony(50_000, oneMore, wge.NewCivilian(3_000, 5)))
+	if s.Checksum() == changed.Checksum() {
+		t.Errorf("checksum: rebel: expected change, got %x\n", s.Checksum())
+	}
+	other := wge.NewSystem(wge.NewColony(1_000, wge.NewCivilian(100, 5)))
+	if wge.GalaxyChecksum(s, other) == wge.GalaxyChecksum(changed, other) {
+		t.Errorf("checksum: galaxy: rebel: expected change, got %x\n", wge.GalaxyChecksum(s, other))
+	}
+	if wge.GalaxyChecksum(s, other) != wge.GalaxyChecksum(wge.NewSystem(loaded...), other) {
+		t.Errorf("checksum: galaxy: load: expected no change\n")
+	}
+}
+
+func TestSystemValidate(t *testing.T) {
+	good := wge.NewColony(10_000, wge.NewCivilian(1_000, 5), wge.NewSoldier(100, 5))
+	if errs := wge.NewSystem(good).Validate(); len(errs) != 0 {
+		t.Errorf("validate: good: expected no errors, got %v\n", errs)
+	}
+	// a colony built past its hard cap and a unit with a bad tech level
+	overbuilt := wge.NewColony(20_000, wge.NewCivilian(1_000, 5)).WithCapacityLimit(15_000)
+	broken := wge.NewColony(10_000, wge.NewCivilian(1_000, 5), wge.NewSoldier(100, 12))
+	errs := wge.NewSystem(good, overbuilt, broken).Validate()
+	if len(errs) != 2 {
+		t.Fatalf("validate: faults: expected 2 errors, got %d: %v\n", len(errs), errs)
+	}
+	for i, prefix := range []string{"colony 1: capacity:", "colony 2: member 1: SLD:"} {
+		if !strings.HasPrefix(errs[i].Error(), prefix) {
+			t.Errorf("validate: faults: %d: expected %q, got %q\n", i, prefix, errs[i].Error())
+		}
+	}
+	// two colonies placed on the same planet
+	earth, mars := wge.NewPlanet("Earth", 10_000, wge.Benign), wge.NewPlanet("Mars", 10_000, wge.Benign)
+	errs = wge.NewSystem(good.WithPlanet(earth), good.WithPlanet(mars), good, good.WithPlanet(earth)).Validate()
+	if len(errs) != 1 {
+		t.Fatalf("validate: planets: expected 1 error, got %d: %v\n", len(errs), errs)
+	}
+	if prefix := `colony 3: planet: "Earth": duplicate id`; !strings.HasPrefix(errs[0].Error(), prefix) {
+		t.Errorf("validate: planets: expected %q, got %q\n", prefix, errs[0].Error())
+	}
+	// the planet id survives a round trip through json
+	data, err := json.Marshal(good.WithPlanet(mars))
+	if err != nil {
+		t.Fatalf("validate: planets: marshal: expected no error, got %v\n", err)
+	}
+	var placed wge.Colony
+	if err := json.Unmarshal(data, &placed); err != nil {
+		t.Fatalf("validate: planets: unmarshal: expected no error, got %v\n", err)
+	} else if placed.Planet() != "Mars" {
+		t.Errorf("validate: planets: unmarshal: expected %q, got %q\n", "Mars", placed.Planet())
+	}
+}
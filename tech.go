@@ -21,3 +21,51 @@ type TechLevel interface {
 	// TechLevel returns the technology level of the unit.
 	TechLevel() int
 }
+
+// referenceTechLevel is the tech level at which the tech multipliers are 1.0.
+const referenceTechLevel = 5
+
+// techFoodFactor returns the multiplier for food needed per person.
+// Each tech level above the reference reduces demand by 5%, and each level
+// below increases it by 5%, so tech 0 needs 1.25 and tech 10 needs 0.75.
+func techFoodFactor(techLevel int) float64 {
+	return 1 - 0.05*float64(clampTechLevel(techLevel)-referenceTechLevel)
+}
+
+// techLifeSupportFactor returns the multiplier for life support needed per person.
+// Each tech level above the reference reduces demand by 8%, and each level
+// below increases it by 8%, so tech 0 needs 1.40 and tech 10 needs 0.60.
+func techLifeSupportFactor(techLevel int) float64 {
+	return 1 - 0.08*float64(clampTechLevel(techLevel)-referenceTechLevel)
+}
+
+// techYieldFactor returns the multiplier for output from production units.
+// Each tech level above the reference increases output by 10%, and each level
+// below reduces it by 10%, so tech 0 yields 0.50 and tech 10 yields 1.50.
+func techYieldFactor(techLevel int) float64 {
+	return 1 + 0.10*float64(clampTechLevel(techLevel)-referenceTechLevel)
+}
+
+// techMassFactor returns the multiplier for the mass of a population.
+// Advanced populations carry more infrastructure per person. Each tech level
+// above the reference adds 5%, so tech 0 is 0.75 and tech 10 is 1.25.
+func techMassFactor(techLevel int) float64 {
+	return 1 + 0.05*float64(clampTechLevel(techLevel)-referenceTechLevel)
+}
+
+// techVolumeFactor returns the multiplier for the volume of a population.
+// Each tech level above the reference adds 10%, so tech 0 is 0.50 and
+// tech 10 is 1.50.
+func techVolumeFactor(techLevel int) float64 {
+	return 1 + 0.10*float64(clampTechLevel(techLevel)-referenceTechLevel)
+}
+
+// clampTechLevel limits a tech level to the range 0 to 10.
+func clampTechLevel(techLevel int) int {
+	if techLevel < 0 {
+		return 0
+	} else if techLevel > 10 {
+		return 10
+	}
+	return techLevel
+}
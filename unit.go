@@ -16,10 +16,26 @@
 
 package wge
 
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"strconv"
+)
+
 // Unit defines the interface for working with units in the game.
 type Unit interface {
 	// Code returns the short display code for the unit.
 	Code() string
+	// Describe returns the attributes of the unit for reports.
+	Describe() UnitDescription
 	// Quantity returns the number of items in the unit.
 	Quantity() float64
 	// Mass returns the mass (in metric tonnes) of the unit.
@@ -27,3 +43,277 @@ type Unit interface {
 	// Volume returns the volume (in cubic meters) required to store the unit.
 	Volume() float64
 }
+
+// UnitDescription holds the attributes common to all units.
+// Reports can use it to list units without knowing their types.
+type UnitDescription struct {
+	Code     string
+	Quantity float64
+	Mass     float64
+	Volume   float64
+}
+
+// describe returns the description of any unit.
+func describe(u Unit) UnitDescription {
+	return UnitDescription{
+		Code:     u.Code(),
+		Quantity: u.Quantity(),
+		Mass:     u.Mass(),
+		Volume:   u.Volume(),
+	}
+}
+
+// auxUnit is a helper to convert a unit of any type to/from json.
+// The code is used to select the concrete type when decoding.
+type auxUnit struct {
+	Code string          `json:"code"`
+	Unit json.RawMessage `json:"unit"`
+}
+
+// CargoCost returns the cargo points needed to carry the unit, a single
+// number a ship's capacity can be checked against. It blends the mass (in
+// tonnes) and the volume (in cubic meters) of the unit: massWeight, clamped
+// to 0 to 1, is the weight of the mass, and the volume gets the rest. A
+// weight of 1 costs by mass alone, 0 by volume alone, and 0.5 counts each
+// tonne and each cubic meter as half a point.
+func CargoCost(u Unit, massWeight float64) float64 {
+	massWeight = clamp(massWeight, 0, 1)
+	return massWeight*u.Mass() + (1-massWeight)*u.Volume()
+}
+
+// DecodeUnits reads newline-delimited JSON from r and calls fn for each unit.
+// Each line must be an object of the form {"code":"CIV","unit":{...}},
+// where the code selects the concrete type of the unit. Blank lines are skipped.
+//
+// Decoding stops at the first error. A malformed or truncated line returns
+// an error that includes the line number. If fn returns an error, decoding
+// stops and that error is returned unchanged.
+func DecodeUnits(r io.Reader, fn func(Unit) error) error {
+	br := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if line = bytes.TrimSpace(line); len(line) != 0 {
+			u, derr := decodeUnit(line)
+			if derr != nil {
+				return fmt.Errorf("line %d: %w", lineNo, derr)
+			}
+			if ferr := fn(u); ferr != nil {
+				return ferr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+	}
+}
+
+// FoodForVoyage returns the FOOD units a ship must carry to feed its cargo
+// for the number of turns. Every population group eats, including
+// soldiers and professionals, using its own FoodNeeded for each turn.
+// Units that aren't population groups need no food.
+func FoodForVoyage(cargo []Unit, turns int) float64 {
+	if turns <= 0 {
+		return 0
+	}
+	var food float64
+	for _, u := range cargo {
+		if pg, ok := u.(PopulationGroup); ok {
+			food += pg.FoodNeeded()
+		}
+	}
+	return food * float64(turns)
+}
+
+// MarshalUnits returns the units as a json array. Each unit is wrapped in
+// an object of the form {"code":"CIV","unit":{...}}, the same format as
+// DecodeUnits, so UnmarshalUnits can restore the concrete types.
+func MarshalUnits(units []Unit) ([]byte, error) {
+	list := make([]auxUnit, 0, len(units))
+	for i, u := range units {
+		data, err := json.Marshal(u)
+		if err != nil {
+			return nil, fmt.Errorf("encode units: %d: %w", i, err)
+		}
+		list = append(list, auxUnit{Code: u.Code(), Unit: data})
+	}
+	return json.Marshal(list)
+}
+
+// MergeUnits merges two units of the same type using the type's own Merge.
+// It returns an error if the codes don't match or the type can't be merged.
+func MergeUnits(a, b Unit) (Unit, error) {
+	if a.Code() != b.Code() {
+		return nil, fmt.Errorf("merge units: can't merge %s with %s", a.Code(), b.Code())
+	}
+	switch a := a.(type) {
+	case Civilian:
+		if b, ok := b.(Civilian); ok {
+			return a.Merge(b), nil
+		}
+	case Soldier:
+		if b, ok := b.(Soldier); ok {
+			return a.Merge(b), nil
+		}
+	}
+	return nil, fmt.Errorf("merge units: can't merge %T with %T", a, b)
+}
+
+// SplitUnit splits qty people off of a unit using the type's own Split.
+// It returns the unit that remains and the unit that was split off,
+// or an error if the type can't be split.
+func SplitUnit(u Unit, qty int) (Unit, Unit, error) {
+	switch u := u.(type) {
+	case Civilian:
+		rest, part, err := u.Split(qty)
+		if err != nil {
+			return u, nil, err
+		}
+		return rest, part, nil
+	case Soldier:
+		rest, part, err := u.Split(qty)
+		if err != nil {
+			return u, nil, err
+		}
+		return rest, part, nil
+	}
+	return u, nil, fmt.Errorf("split unit: can't split %T", u)
+}
+
+// UnmarshalUnits returns the units from a json array written by
+// MarshalUnits. The code of each element selects the concrete type.
+func UnmarshalUnits(data []byte) ([]Unit, error) {
+	var list []auxUnit
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("decode units: %w", err)
+	}
+	units := make([]Unit, 0, len(list))
+	for i, aux := range list {
+		u, err := unmarshalUnit(aux.Code, aux.Unit)
+		if err != nil {
+			return nil, fmt.Errorf("decode units: %d: %w", i, err)
+		}
+		units = append(units, u)
+	}
+	return units, nil
+}
+
+// decodeUnit converts a single json object into a unit.
+func decodeUnit(data []byte) (Unit, error) {
+	var aux auxUnit
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return nil, fmt.Errorf("decode unit: %w", err)
+	}
+	return unmarshalUnit(aux.Code, aux.Unit)
+}
+
+// freeToLeave returns the number of people that can be split off of a
+// population group with SplitUnit: everyone but the garrison of civilians.
+func freeToLeave(pg PopulationGroup) int {
+	if p, ok := pg.(Civilian); ok {
+		return p.Population() - p.garrison
+	}
+	return pg.Population()
+}
+
+// killUnit returns the unit after deaths of its people. Civilians lose
+// them as in Civilian.kill, so the garrison dies last, and frozen
+// civilians lose no one. Deaths are limited to the population, and units
+// that aren't population groups are returned unchanged.
+func killUnit(u Unit, deaths int) Unit {
+	if deaths <= 0 {
+		return u
+	}
+	switch u := u.(type) {
+	case Civilian:
+		if u.frozen {
+			return u
+		}
+		return u.kill(deaths, 0)
+	case Soldier:
+		if deaths > u.qty {
+			deaths = u.qty
+		}
+		u.qty -= deaths
+		return u
+	case Professional:
+		if deaths > u.qty {
+			deaths = u.qty
+		}
+		u.qty -= deaths
+		return u
+	}
+	return u
+}
+
+// unitFingerprint returns a 64-bit hash of the state of a unit.
+// Units with a Fingerprint method use it. Other units hash their code,
+// quantity, mass, and volume, which change with their size and tech level.
+func unitFingerprint(u Unit) uint64 {
+	if f, ok := u.(interface{ Fingerprint() uint64 }); ok {
+		return f.Fingerprint()
+	}
+	d := u.Describe()
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(d.Code))
+	var buf [8]byte
+	for _, v := range []float64{d.Quantity, d.Mass, d.Volume} {
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+		_, _ = h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// unmarshalUnit is the factory that creates a unit from its code and json data.
+func unmarshalUnit(code string, data []byte) (Unit, error) {
+	switch code {
+	case "CIV":
+		var p Civilian
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, err
+		}
+		return p, nil
+	case "PRO":
+		var p Professional
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, err
+		}
+		return p, nil
+	case "SLD":
+		var s Soldier
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("decode unit: unknown code %q", code)
+}
+
+// WriteUnitsCSV writes the units to w as CSV with a header row.
+// The columns are code, population, rebels, tech, mass, and volume.
+// Population and rebels are empty for units that aren't population groups,
+// and tech is empty for units without a tech level.
+func WriteUnitsCSV(w io.Writer, units []Unit) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"code", "population", "rebels", "tech", "mass", "volume"}); err != nil {
+		return err
+	}
+	for _, u := range units {
+		var pop, rebels, tech string
+		if pg, ok := u.(PopulationGroup); ok {
+			pop, rebels = strconv.Itoa(pg.Population()), strconv.Itoa(pg.Rebels())
+		}
+		if tl, ok := u.(TechLevel); ok {
+			tech = strconv.Itoa(tl.TechLevel())
+		}
+		mass := strconv.FormatFloat(u.Mass(), 'f', -1, 64)
+		volume := strconv.FormatFloat(u.Volume(), 'f', -1, 64)
+		if err := cw.Write([]string{u.Code(), pop, rebels, tech, mass, volume}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
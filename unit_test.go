@@ -0,0 +1,262 @@
+// wge - the wraith game engine
+// Copyright (C) 2023 Michael D Henderson
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wge_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/maloquacious/wge"
+)
+
+func TestDecodeUnits(t *testing.T) {
+	// verify that a stream of units is decoded in order
+	stream := `{"code":"CIV","unit":{"loyal-citizens":100,"rebel-citizens":5,"tech-level":2}}
+{"code":"SLD","unit":{"soldiers":200,"tech-level":4}}
+
+{"code":"CIV","unit":{"loyal-citizens":300,"rebel-citizens":10,"tech-level":6}}`
+	var units []wge.Unit
+	if err := wge.DecodeUnits(strings.NewReader(stream), func(u wge.Unit) error {
+		units = append(units, u)
+		return nil
+	}); err != nil {
+		t.Fatalf("decode: expected nil, got %v\n", err)
+	}
+	if len(units) != 3 {
+		t.Fatalf("decode: expected 3 units, got %d\n", len(units))
+	}
+	for i, tc := range []struct {
+		code   string
+		pop    int
+		rebels int
+		tech   int
+	}{
+		{"CIV", 105, 5, 2},
+		{"SLD", 200, 0, 4},
+		{"CIV", 310, 10, 6},
+	} {
+		if units[i].Code() != tc.code {
+			t.Errorf("decode: %d: expected code %q, got %q\n", i+1, tc.code, units[i].Code())
+		}
+		p, ok := units[i].(wge.PopulationGroup)
+		if !ok {
+			t.Errorf("decode: %d: expected PopulationGroup, got %T\n", i+1, units[i])
+			continue
+		}
+		tech := units[i].(wge.TechLevel).TechLevel()
+		if p.Population() != tc.pop || p.Rebels() != tc.rebels || tech != tc.tech {
+			t.Errorf("decode: %d: expected %d/%d/%d, got %d/%d/%d\n", i+1, tc.pop, tc.rebels, tc.tech, p.Population(), p.Rebels(), tech)
+		}
+	}
+
+	// verify that a truncated final line and an unknown code return errors
+	for _, tc := range []struct {
+		id     int
+		stream string
+	}{
+		{1, "{\"code\":\"CIV\",\"unit\":{\"loyal-citizens\":100}}\n{\"code\":\"CIV\",\"unit\":{\"loyal-cit"},
+		{2, "{\"code\":\"XYZ\",\"unit\":{}}\n"},
+	} {
+		count := 0
+		err := wge.DecodeUnits(strings.NewReader(tc.stream), func(u wge.Unit) error {
+			count++
+			return nil
+		})
+		if err == nil {
+			t.Errorf("decode: error %d: expected error, got nil\n", tc.id)
+		}
+		if tc.id == 1 && count != 1 {
+			t.Errorf("decode: error %d: expected 1 unit before error, got %d\n", tc.id, count)
+		}
+	}
+}
+
+func TestUnitDescribe(t *testing.T) {
+	for _, tc := range []struct {
+		id     int
+		unit   wge.Unit
+		expect wge.UnitDescription
+	}{
+		{1, wge.NewCivilian(0, 5), wge.UnitDescription{Code: "CIV"}},
+		{2, wge.NewCivilian(2_500, 5), wge.UnitDescription{Code: "CIV", Quantity: 25, Mass: 25, Volume: 25}},
+	} {
+		got := tc.unit.Describe()
+		if got.Code != tc.expect.Code || !isClose(got.Quantity, tc.expect.Quantity) || !isClose(got.Mass, tc.expect.Mass) || !isClose(got.Volume, tc.expect.Volume) {
+			t.Errorf("describe: %d: expected %+v, got %+v\n", tc.id, tc.expect, got)
+		}
+	}
+
+	// the description tracks changes to the unit
+	p := wge.NewCivilian(1_000, 5).Merge(wge.NewCivilian(500, 5))
+	if got := p.Describe(); !isClose(15, got.Quantity) {
+		t.Errorf("describe: merged: expected quantity %8.4f, got %8.4f\n", 15.0, got.Quantity)
+	}
+}
+
+// crate is a unit that isn't a population group.
+type crate struct {
+	qty float64
+}
+
+func (c crate) Code() string                  { return "CRT" }
+func (c crate) Describe() wge.UnitDescription { return wge.UnitDescription{Code: c.Code()} }
+func (c crate) Mass() float64                 { return c.qty * 0.5 }
+func (c crate) Quantity() float64             { return c.qty }
+func (c crate) Volume() float64               { return c.qty * 2 }
+
+func TestWriteUnitsCSV(t *testing.T) {
+	var civ wge.Civilian
+	if err := json.Unmarshal([]byte(`{"loyal-citizens":1200,"rebel-citizens":50,"tech-level":3}`), &civ); err != nil {
+		t.Fatalf("csv: expected nil, got %v\n", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := wge.WriteUnitsCSV(buf, []wge.Unit{civ, crate{qty: 3}}); err != nil {
+		t.Fatalf("csv: expected nil, got %v\n", err)
+	}
+	expect := "code,population,rebels,tech,mass,volume\n" +
+		"CIV,1250,50,3,11.25,10\n" +
+		"CRT,,,,1.5,6\n"
+	if got := buf.String(); got != expect {
+		t.Errorf("csv: expected\n%s\ngot\n%s\n", expect, got)
+	}
+
+	// the header is written even when there are no units
+	buf.Reset()
+	if err := wge.WriteUnitsCSV(buf, nil); err != nil {
+		t.Fatalf("csv: empty: expected nil, got %v\n", err)
+	}
+	if got := buf.String(); got != "code,population,rebels,tech,mass,volume\n" {
+		t.Errorf("csv: empty: expected header only, got %q\n", got)
+	}
+}
+
+func TestMergeUnits(t *testing.T) {
+	// same-code merges dispatch to the type's Merge
+	u, err := wge.MergeUnits(wge.NewCivilian(100, 2), wge.NewCivilian(100, 4))
+	if err != nil {
+		t.Fatalf("mergeUnits: civilians: expected nil, got %v\n", err)
+	}
+	if expect := wge.NewCivilian(100, 2).Merge(wge.NewCivilian(100, 4)); !expect.Equal(u.(wge.Civilian)) {
+		t.Errorf("mergeUnits: civilians: expected %+v, got %+v\n", expect, u)
+	}
+	u, err = wge.MergeUnits(wge.NewSoldier(100, 2), wge.NewSoldier(300, 6))
+	if err != nil {
+		t.Fatalf("mergeUnits: soldiers: expected nil, got %v\n", err)
+	}
+	if got := u.(wge.Soldier); got.Population() != 400 || got.TechLevel() != 5 {
+		t.Errorf("mergeUnits: soldiers: expected 400/5, got %d/%d\n", got.Population(), got.TechLevel())
+	}
+
+	// cross-code merges are rejected
+	if _, err := wge.MergeUnits(wge.NewCivilian(100, 2), wge.NewSoldier(100, 2)); err == nil {
+		t.Errorf("mergeUnits: cross-code: expected error, got nil\n")
+	}
+	// types without a merge are rejected
+	if _, err := wge.MergeUnits(crate{qty: 1}, crate{qty: 2}); err == nil {
+		t.Errorf("mergeUnits: crates: expected error, got nil\n")
+	}
+}
+
+func TestMarshalUnits(t *testing.T) {
+	rebels, _ := wge.NewCivilianBuilder().Loyal(900).Rebel(100).Tech(4).Build()
+	units := []wge.Unit{rebels, wge.NewSoldier(250, 6).WithShip(true), wge.NewCivilian(50, 2)}
+	data, err := wge.MarshalUnits(units)
+	if err != nil {
+		t.Fatalf("marshalUnits: expected nil, got %v\n", err)
+	}
+	got, err := wge.UnmarshalUnits(data)
+	if err != nil {
+		t.Fatalf("unmarshalUnits: expected nil, got %v\n", err)
+	} else if len(got) != len(units) {
+		t.Fatalf("unmarshalUnits: expected %d units, got %d\n", len(units), len(got))
+	}
+	for i := range units {
+		if got[i] != units[i] {
+			t.Errorf("unmarshalUnits: %d: expected %+v, got %+v\n", i, units[i], got[i])
+		}
+	}
+	// an empty list is an empty array
+	if data, err := wge.MarshalUnits(nil); err != nil || string(data) != "[]" {
+		t.Errorf("marshalUnits: empty: expected [], got %s %v\n", data, err)
+	}
+	for _, tc := range []struct {
+		id   int
+		data string
+	}{
+		{1, `[{"code":"XYZ","unit":{}}]`},
+		{2, `[{"code":"CIV","unit":{"loyal-citizens":-1,"rebel-citizens":0,"tech-level":5}}]`},
+		{3, `{"code":"CIV"}`},
+	} {
+		if _, err := wge.UnmarshalUnits([]byte(tc.data)); err == nil {
+			t.Errorf("unmarshalUnits: %d: expected error, got nil\n", tc.id)
+		}
+	}
+}
+
+func TestFoodForVoyage(t *testing.T) {
+	civilians, soldiers := wge.NewCivilian(10_000, 5), wge.NewSoldier(2_000, 5)
+	cargo := []wge.Unit{civilians, soldiers, crate{qty: 10}}
+	// 100 civilians and 20 soldiers at 0.0125 FOOD each for three turns
+	if got := wge.FoodForVoyage(cargo, 3); !isClose(4.5, got) {
+		t.Errorf("foodForVoyage: expected 4.5, got %g\n", got)
+	}
+	if got, expect := wge.FoodForVoyage(cargo, 3), 3*(civilians.FoodNeeded()+soldiers.FoodNeeded()); !isClose(expect, got) {
+		t.Errorf("foodForVoyage: expected %g, got %g\n", expect, got)
+	}
+	if got := wge.FoodForVoyage(cargo, 0); got != 0 {
+		t.Errorf("foodForVoyage: no turns: expected 0, got %g\n", got)
+	}
+}
+
+// ingot is a dense unit: heavy for its size.
+type ingot struct {
+	qty float64
+}
+
+func (i ingot) Code() string                  { return "ING" }
+func (i ingot) Describe() wge.UnitDescription { return wge.UnitDescription{Code: i.Code()} }
+func (i ingot) Mass() float64                 { return i.qty * 4 }
+func (i ingot) Quantity() float64             { return i.qty }
+func (i ingot) Volume() float64               { return i.qty * 0.5 }
+
+func TestCargoCost(t *testing.T) {
+	// the ingots weigh 40 tonnes in 5 cubic meters,
+	// the crates weigh 5 tonnes in 20 cubic meters.
+	dense, bulky := ingot{qty: 10}, crate{qty: 10}
+	for _, tc := range []struct {
+		id             int
+		massWeight     float64
+		dense, bulky   float64
+		denseCostsMore bool
+	}{
+		{1, 1, 40, 5, true},
+		{2, 0, 5, 20, false},
+		{3, 0.5, 22.5, 12.5, true},
+		{4, 0.2, 12, 17, false},
+		{5, 2, 40, 5, true},
+	} {
+		d, b := wge.CargoCost(dense, tc.massWeight), wge.CargoCost(bulky, tc.massWeight)
+		if !isClose(tc.dense, d) || !isClose(tc.bulky, b) {
+			t.Errorf("cargoCost: %d: expected %g/%g, got %g/%g\n", tc.id, tc.dense, tc.bulky, d, b)
+		}
+		if (d > b) != tc.denseCostsMore {
+			t.Errorf("cargoCost: %d: expected dense costs more %v, got %v\n", tc.id, tc.denseCostsMore, d > b)
+		}
+	}
+}